@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// asOfCmd represents the as-of command
+var asOfCmd = &cobra.Command{
+	Use:   "as-of",
+	Short: "Print a document as it was at a block height",
+	Long: `Connects to MongoDB and prints the document of the hex encoded BadgerDB key --as-of-key
+as it was at block height --as-of-height, using the versions kept by --mongo-history.`,
+	Run: AsOf,
+}
+
+func AsOf(cmd *cobra.Command, args []string) {
+	key, err := hex.DecodeString(viper.GetString("as-of-key"))
+	cobra.CheckErr(err)
+	if len(key) == 0 {
+		cobra.CheckErr(fmt.Errorf("--as-of-key is required"))
+	}
+
+	mongoConfig := LoadConfig()
+	sink, err := NewSink(mongoConfig)
+	cobra.CheckErr(err)
+	mongoSink, ok := sink.(*mongodb.MongoSink)
+	if !ok {
+		cobra.CheckErr(fmt.Errorf("as-of requires the %v sink", mongodb.SinkTypeMongo))
+	}
+	cobra.CheckErr(mongoSink.Connect(context.Background()))
+	defer mongoSink.Close()
+
+	height := viper.GetUint64("as-of-height")
+	doc, err := mongoSink.FindAsOfHeight(key, height)
+	cobra.CheckErr(err)
+	if doc == nil {
+		fmt.Printf("No version of the document is known at block height %d.\n", height)
+		return
+	}
+
+	docJSON, err := bson.MarshalExtJSON(doc, false, false)
+	cobra.CheckErr(err)
+	fmt.Println(string(docJSON))
+}
+
+func init() {
+	asOfCmd.PersistentFlags().String("as-of-key", "", "Hex encoded BadgerDB key of the document")
+	asOfCmd.PersistentFlags().Uint64("as-of-height", 0, "Block height to read the document at")
+
+	asOfCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		viper.BindPFlag(flag.Name, flag)
+	})
+
+	rootCmd.AddCommand(asOfCmd)
+}
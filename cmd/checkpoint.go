@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// checkpointCmd represents the checkpoint command
+var checkpointCmd = &cobra.Command{
+	Use:   "checkpoint",
+	Short: "Print the stored sync checkpoint",
+	Long: `Connects to MongoDB and prints the checkpoint the dumper resumes from on restart.
+Run the dumper with --mongo-resync to discard it and sync from scratch.`,
+	Run: Checkpoint,
+}
+
+func Checkpoint(cmd *cobra.Command, args []string) {
+	mongoConfig := LoadConfig()
+	sink, err := NewSink(mongoConfig)
+	cobra.CheckErr(err)
+	cobra.CheckErr(sink.Connect(context.Background()))
+	defer sink.Close()
+
+	checkpoint, err := sink.GetCheckpoint()
+	cobra.CheckErr(err)
+	if checkpoint == nil {
+		fmt.Println("No sync checkpoint stored.")
+		return
+	}
+
+	checkpointJSON, err := json.MarshalIndent(checkpoint, "", "  ")
+	cobra.CheckErr(err)
+	fmt.Println(string(checkpointJSON))
+}
+
+func init() {
+	rootCmd.AddCommand(checkpointCmd)
+}
@@ -1,6 +1,12 @@
 package cmd
 
-import "github.com/spf13/viper"
+import (
+	"fmt"
+	"time"
+
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
+	"github.com/spf13/viper"
+)
 
 type Mode string
 type Network string
@@ -9,6 +15,35 @@ type Config struct {
 	MongoURI        string
 	MongoDatabase   string
 	MongoCollection string
+	MongoSyncMode   mongodb.SyncMode
+
+	MongoMetadataCollection string
+	MongoResync             bool
+	MongoSoftDelete         bool
+	MongoCollectionLayout   mongodb.CollectionLayout
+	MongoCollectionMap      []string
+	MongoHistory            bool
+	MongoHistoryPrefixes    []int
+
+	MongoDeadLetterCollection string
+	DeadLetterStore           mongodb.DeadLetterStoreType
+	DeadLetterPath            string
+
+	Sink         mongodb.SinkType
+	SinkJSONPath string
+
+	RecordEncoding mongodb.RecordEncoding
+
+	HashCacheDir   string
+	ScanWorkers    int
+	WriteBatchSize int
+	Writers        int
+
+	WriteAttempts   int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+
+	ShutdownTimeout time.Duration
 }
 
 func LoadConfig() *Config {
@@ -17,6 +52,115 @@ func LoadConfig() *Config {
 	config.MongoURI = viper.GetString("mongo-uri")
 	config.MongoDatabase = viper.GetString("mongo-database")
 	config.MongoCollection = viper.GetString("mongo-collection")
+	config.MongoSyncMode = mongodb.SyncMode(viper.GetString("mongo-sync-mode"))
+
+	config.MongoMetadataCollection = viper.GetString("mongo-metadata-collection")
+	config.MongoResync = viper.GetBool("mongo-resync")
+	config.MongoSoftDelete = viper.GetBool("mongo-soft-delete")
+	config.MongoCollectionLayout = mongodb.CollectionLayout(viper.GetString("mongo-collection-layout"))
+	config.MongoCollectionMap = viper.GetStringSlice("mongo-collection-map")
+	config.MongoHistory = viper.GetBool("mongo-history")
+	config.MongoHistoryPrefixes = viper.GetIntSlice("mongo-history-prefixes")
+
+	config.MongoDeadLetterCollection = viper.GetString("mongo-dead-letter-collection")
+	config.DeadLetterStore = mongodb.DeadLetterStoreType(viper.GetString("dead-letter-store"))
+	config.DeadLetterPath = viper.GetString("dead-letter-path")
+
+	config.Sink = mongodb.SinkType(viper.GetString("sink"))
+	config.SinkJSONPath = viper.GetString("sink-json-path")
+
+	config.RecordEncoding = mongodb.RecordEncoding(viper.GetString("record-encoding"))
+
+	config.HashCacheDir = viper.GetString("hash-cache-dir")
+	config.ScanWorkers = viper.GetInt("scan-workers")
+	config.WriteBatchSize = viper.GetInt("write-batch-size")
+	config.Writers = viper.GetInt("writers")
+
+	config.WriteAttempts = viper.GetInt("write-attempts")
+	config.RetryBackoff = viper.GetDuration("retry-backoff")
+	config.RetryMaxBackoff = viper.GetDuration("retry-max-backoff")
+
+	config.ShutdownTimeout = viper.GetDuration("shutdown-timeout")
 
 	return &config
 }
+
+// Returns the retries of failed sink connections and bulk writes configured by config
+func NewRetryPolicy(config *Config) mongodb.RetryPolicy {
+	return mongodb.RetryPolicy{
+		WriteAttempts:  config.WriteAttempts,
+		InitialBackoff: config.RetryBackoff,
+		MaxBackoff:     config.RetryMaxBackoff,
+	}
+}
+
+// Creates the sink selected by config
+func NewSink(config *Config) (mongodb.Sink, error) {
+	switch config.Sink {
+	case mongodb.SinkTypeMongo, "":
+		var prefixCollections map[byte]string
+		switch config.MongoCollectionLayout {
+		case mongodb.CollectionLayoutPerPrefix, "":
+			var err error
+			prefixCollections, err = mongodb.ParsePrefixCollections(config.MongoCollectionMap)
+			if err != nil {
+				return nil, err
+			}
+		case mongodb.CollectionLayoutSingle:
+		default:
+			return nil, fmt.Errorf("Unknown collection layout %q", config.MongoCollectionLayout)
+		}
+
+		var historyPrefixes map[byte]bool
+		if config.MongoHistory {
+			var err error
+			historyPrefixes, err = mongodb.ParseHistoryPrefixes(config.MongoHistoryPrefixes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return mongodb.NewMongoSink(
+			config.MongoURI,
+			config.MongoDatabase,
+			config.MongoCollection,
+			config.MongoMetadataCollection,
+			config.MongoDeadLetterCollection,
+			prefixCollections,
+			config.MongoSoftDelete,
+			historyPrefixes), nil
+	case mongodb.SinkTypeJSON:
+		return mongodb.NewJSONSink(config.SinkJSONPath), nil
+	default:
+		return nil, fmt.Errorf("Unknown sink %q", config.Sink)
+	}
+}
+
+// Creates the dead-letter store selected by config. Returns nil if dead
+// letters are disabled. The mongo store shares the connection of sink.
+func NewDeadLetterStore(config *Config, sink mongodb.Sink) (mongodb.DeadLetterStore, error) {
+	switch config.DeadLetterStore {
+	case mongodb.DeadLetterStoreNone, "":
+		return nil, nil
+	case mongodb.DeadLetterStoreMongo:
+		mongoSink, ok := sink.(*mongodb.MongoSink)
+		if !ok {
+			return nil, fmt.Errorf("The %v dead-letter store requires the %v sink",
+				mongodb.DeadLetterStoreMongo, mongodb.SinkTypeMongo)
+		}
+		return mongoSink, nil
+	case mongodb.DeadLetterStoreFile:
+		return mongodb.NewFileDeadLetterStore(config.DeadLetterPath), nil
+	default:
+		return nil, fmt.Errorf("Unknown dead-letter store %q", config.DeadLetterStore)
+	}
+}
+
+// Opens the hash cache selected by config. Returns nil if unchanged records
+// aren't skipped.
+func OpenHashCache(config *Config) (*mongodb.HashCache, error) {
+	if config.HashCacheDir == "" {
+		return nil, nil
+	}
+	return mongodb.OpenHashCache(config.HashCacheDir)
+}
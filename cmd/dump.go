@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
+	"github.com/dgraph-io/badger/v3"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// dumpCmd represents the dump command
+var dumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump an existing BadgerDB directory without starting a node",
+	Long: `Opens the BadgerDB directory of a stopped node or a snapshot read-only,
+writes every key to the sink once and exits. The sync checkpoint is left untouched.`,
+	Run: Dump,
+}
+
+// Opens the BadgerDB directory given by --badger-dir read-only
+func openBadgerDir() *badger.DB {
+	badgerDir := viper.GetString("badger-dir")
+	if badgerDir == "" {
+		cobra.CheckErr(fmt.Errorf("--badger-dir is required"))
+	}
+
+	db, err := badger.Open(badger.DefaultOptions(badgerDir).WithReadOnly(true))
+	cobra.CheckErr(err)
+	return db
+}
+
+func Dump(cmd *cobra.Command, args []string) {
+	db := openBadgerDir()
+	defer db.Close()
+
+	config := LoadConfig()
+	sink, err := NewSink(config)
+	cobra.CheckErr(err)
+	deadLetters, err := NewDeadLetterStore(config, sink)
+	cobra.CheckErr(err)
+	hashCache, err := OpenHashCache(config)
+	cobra.CheckErr(err)
+	if hashCache != nil {
+		defer hashCache.Close()
+	}
+	cobra.CheckErr(sink.Connect(context.Background()))
+	defer sink.Close()
+
+	syncingService := mongodb.NewSyncingService(db, sink, mongodb.SyncModeFull, false,
+		config.RecordEncoding, deadLetters, hashCache, config.ScanWorkers,
+		config.WriteBatchSize, config.Writers, NewRetryPolicy(config))
+	// Stop early on an interrupt, after writing what was read so far
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	summary, err := syncingService.Dump(ctx)
+	if ctx.Err() != nil {
+		fmt.Println("Dump interrupted after writing the keys read so far.")
+		return
+	}
+	if err != nil {
+		fmt.Printf("Dump failed: %v\n", err)
+		return
+	}
+
+	summary.PrefixStats.Print()
+	fmt.Printf("Dumped %d of %d BadgerDB keys in %v (%d skipped, %d unchanged writes skipped).\n",
+		summary.RecordsUpserted, summary.KeysScanned, summary.Duration,
+		summary.KeysScanned-summary.RecordsUpserted, summary.RecordsUnchanged)
+}
+
+func init() {
+	rootCmd.AddCommand(dumpCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
+	"github.com/spf13/cobra"
+)
+
+// migrateIDsCmd represents the migrate-ids command
+var migrateIDsCmd = &cobra.Command{
+	Use:   "migrate-ids",
+	Short: "Rewrite documents written by older versions to use hex _ids",
+	Long: `Older versions used the raw BadgerDB key cast to a string as the document _id.
+This rewrites every such document to use the hex encoded key as _id, stores the raw key
+in the BadgerKey field and moves the document to the collection its prefix maps to.
+Stop the dumper before running it.`,
+	Run: MigrateIDs,
+}
+
+func MigrateIDs(cmd *cobra.Command, args []string) {
+	config := LoadConfig()
+	sink, err := NewSink(config)
+	cobra.CheckErr(err)
+
+	mongoSink, ok := sink.(*mongodb.MongoSink)
+	if !ok {
+		cobra.CheckErr(fmt.Errorf("migrate-ids only supports the %v sink", mongodb.SinkTypeMongo))
+	}
+	cobra.CheckErr(mongoSink.Connect(context.Background()))
+	defer mongoSink.Close()
+
+	totalMigrated, err := mongoSink.MigrateDocumentIDs()
+	if err != nil {
+		fmt.Printf("Migration failed after %d documents: %v\n", totalMigrated, err)
+		return
+	}
+	fmt.Printf("Migrated %d documents.\n", totalMigrated)
+}
+
+func init() {
+	rootCmd.AddCommand(migrateIDsCmd)
+}
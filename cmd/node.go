@@ -1,15 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
 	coreCmd "github.com/deso-protocol/core/cmd"
 	"github.com/deso-protocol/mongodb-dumper/mongodb"
 )
 
 type Node struct {
 	SyncingService *mongodb.SyncingService
+	Sink           mongodb.Sink
+	HashCache      *mongodb.HashCache
 	Config         *Config
 
-	CoreNode       *coreCmd.Node
+	CoreNode *coreCmd.Node
+
+	// cancelSync stops the SyncingService and syncStopped is closed once it returned
+	cancelSync  context.CancelFunc
+	syncStopped chan struct{}
 }
 
 func NewNode(config *Config, coreNode *coreCmd.Node) *Node {
@@ -21,18 +32,67 @@ func NewNode(config *Config, coreNode *coreCmd.Node) *Node {
 }
 
 func (node *Node) Start() {
+	sink, err := NewSink(node.Config)
+	if err != nil {
+		log.Fatalf("Failed to create sink: %v", err)
+	}
+	node.Sink = sink
+
+	deadLetters, err := NewDeadLetterStore(node.Config, node.Sink)
+	if err != nil {
+		log.Fatalf("Failed to create dead-letter store: %v", err)
+	}
+
+	node.HashCache, err = OpenHashCache(node.Config)
+	if err != nil {
+		log.Fatalf("Failed to open hash cache: %v", err)
+	}
+
 	node.SyncingService = mongodb.NewSyncingService(
 		node.CoreNode.Server.GetBlockchain().DB(),
-		node.Config.MongoURI,
-		node.Config.MongoDatabase,
-		node.Config.MongoCollection)
+		node.Sink,
+		node.Config.MongoSyncMode,
+		node.Config.MongoResync,
+		node.Config.RecordEncoding,
+		deadLetters,
+		node.HashCache,
+		node.Config.ScanWorkers,
+		node.Config.WriteBatchSize,
+		node.Config.Writers,
+		NewRetryPolicy(node.Config))
 
+	ctx, cancel := context.WithCancel(context.Background())
+	node.cancelSync = cancel
+	node.syncStopped = make(chan struct{})
 	go func() {
-		node.SyncingService.ConnectToMongo()
-		node.SyncingService.Start()
+		defer close(node.syncStopped)
+		// Keep the core node running while the sink is unavailable
+		if err := node.SyncingService.Connect(ctx); err != nil {
+			return
+		}
+		node.SyncingService.Start(ctx)
 	}()
 }
 
-func (node *Node) Stop() {
-	node.SyncingService.DisconnectFromMongo()
+// Stops the SyncingService, waiting up to the configured shutdown timeout for
+// it to finish its writes and save its checkpoint, and closes the sink. Returns
+// false if the sync didn't stop in time, in which case it may still be using the
+// sink, the hash cache and the core node's database, so nothing is closed.
+func (node *Node) Stop() bool {
+	if node.cancelSync != nil {
+		node.cancelSync()
+		select {
+		case <-node.syncStopped:
+		case <-time.After(node.Config.ShutdownTimeout):
+			fmt.Printf("Sync didn't stop within %v, exiting without a clean stop. "+
+				"The sync resumes from its last saved checkpoint on restart.\n", node.Config.ShutdownTimeout)
+			return false
+		}
+	}
+
+	node.Sink.Close()
+	if node.HashCache != nil {
+		node.HashCache.Close()
+	}
+	return true
 }
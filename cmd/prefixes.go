@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
+	"github.com/spf13/cobra"
+)
+
+// prefixesCmd represents the prefixes command
+var prefixesCmd = &cobra.Command{
+	Use:   "prefixes",
+	Short: "Print how many keys of each prefix in a BadgerDB directory can be decoded",
+	Long: `Opens the BadgerDB directory of a stopped node or a snapshot read-only, decodes
+every key without writing it anywhere and prints a table with the number of keys
+seen, decoded, failed and skipped per prefix.`,
+	Run: Prefixes,
+}
+
+func Prefixes(cmd *cobra.Command, args []string) {
+	db := openBadgerDir()
+	defer db.Close()
+
+	stats, err := mongodb.ScanPrefixCoverage(db)
+	if err != nil {
+		fmt.Printf("Scan failed: %v\n", err)
+		return
+	}
+	stats.PrintCoverage()
+}
+
+func init() {
+	rootCmd.AddCommand(prefixesCmd)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
+	"github.com/spf13/cobra"
+)
+
+// retryDeadLettersCmd represents the retry-dead-letters command
+var retryDeadLettersCmd = &cobra.Command{
+	Use:   "retry-dead-letters",
+	Short: "Reprocess the records recorded in the dead-letter store",
+	Long: `Reads the current value of every dead letter's key from the BadgerDB directory of a
+stopped node, decodes it again and writes the result to the sink, e.g. after upgrading to a
+dumper with a decoder fix. Keys deleted since they failed are deleted from the sink. Letters
+that sync are removed from the dead-letter store and letters that fail again are kept with
+their new error.`,
+	Run: RetryDeadLetters,
+}
+
+func RetryDeadLetters(cmd *cobra.Command, args []string) {
+	db := openBadgerDir()
+	defer db.Close()
+
+	config := LoadConfig()
+	sink, err := NewSink(config)
+	cobra.CheckErr(err)
+	deadLetters, err := NewDeadLetterStore(config, sink)
+	cobra.CheckErr(err)
+	if deadLetters == nil {
+		cobra.CheckErr(fmt.Errorf("--dead-letter-store is required"))
+	}
+	hashCache, err := OpenHashCache(config)
+	cobra.CheckErr(err)
+	if hashCache != nil {
+		defer hashCache.Close()
+	}
+	cobra.CheckErr(sink.Connect(context.Background()))
+	defer sink.Close()
+
+	syncingService := mongodb.NewSyncingService(db, sink, mongodb.SyncModeFull, false,
+		config.RecordEncoding, deadLetters, hashCache, 1, config.WriteBatchSize, 1, NewRetryPolicy(config))
+	summary, err := syncingService.RetryDeadLetters()
+	if err != nil {
+		fmt.Printf("Retrying dead letters failed: %v\n", err)
+	}
+	if summary != nil {
+		fmt.Printf("Retried %d dead letters, %d succeeded and %d failed again.\n",
+			summary.Retried, summary.Succeeded, summary.Failed)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(retryDeadLettersCmd)
+}
@@ -3,11 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"runtime"
 	"strings"
 
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
 	"github.com/spf13/cobra"
 
 	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -28,6 +31,69 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.deso/mongodb-dumper.yaml)")
+
+	// Add the sink flags shared by all commands
+	rootCmd.PersistentFlags().String("mongo-uri", "mongodb://localhost:27017", "Mongo connection URI")
+	rootCmd.PersistentFlags().String("mongo-database", "deso", "Mongo database name")
+	rootCmd.PersistentFlags().String("mongo-collection", "data",
+		"Mongo collection name. Holds every document in the single layout and prefixes without "+
+			"a mapped collection in the per-prefix layout")
+	rootCmd.PersistentFlags().String("mongo-collection-layout", string(mongodb.CollectionLayoutPerPrefix),
+		"Mongo collection layout. \"per-prefix\" writes each key prefix to its own collection, "+
+			"\"single\" writes everything to --mongo-collection")
+	rootCmd.PersistentFlags().StringSlice("mongo-collection-map", nil,
+		"Overrides of the per-prefix collection mapping, e.g. 17=posts,23=profiles")
+	rootCmd.PersistentFlags().Bool("mongo-history", false,
+		"Keep the previous versions of changed and deleted documents in <collection>_history collections")
+	rootCmd.PersistentFlags().IntSlice("mongo-history-prefixes", defaultHistoryPrefixes(),
+		"Key prefixes whose previous versions are kept with --mongo-history")
+	rootCmd.PersistentFlags().String("mongo-metadata-collection", "sync_metadata",
+		"Mongo collection name for sync metadata such as the checkpoint")
+	rootCmd.PersistentFlags().String("mongo-dead-letter-collection", "dead_letters",
+		"Mongo collection name for records that failed to sync when --dead-letter-store is \"mongo\"")
+	rootCmd.PersistentFlags().String("dead-letter-store", string(mongodb.DeadLetterStoreNone),
+		"Where to record records that fail to decode or write. \"none\" only logs them, "+
+			"\"mongo\" writes them to --mongo-dead-letter-collection, \"file\" to --dead-letter-path")
+	rootCmd.PersistentFlags().String("dead-letter-path", "dead_letters.jsonl",
+		"File the file dead-letter store writes to")
+	rootCmd.PersistentFlags().String("sink", string(mongodb.SinkTypeMongo),
+		"Where to write the dump. \"mongo\" writes to MongoDB, \"json\" writes newline delimited JSON")
+	rootCmd.PersistentFlags().String("sink-json-path", "-",
+		"File the json sink appends to, or \"-\" for stdout")
+	rootCmd.PersistentFlags().String("record-encoding", string(mongodb.RecordEncodingBSON),
+		"How decoded records are encoded. \"bson\" keeps integer and binary types, "+
+			"\"json\" round-trips through JSON like older versions and is only meant for debugging")
+	rootCmd.PersistentFlags().String("hash-cache-dir", "",
+		"Directory of a local database of record content hashes. When set, records whose content "+
+			"didn't change since they were last written are skipped")
+	rootCmd.PersistentFlags().Int("scan-workers", runtime.NumCPU(),
+		"Number of goroutines decoding keys during full scans. 1 iterates, decodes and writes serially")
+	rootCmd.PersistentFlags().Int("write-batch-size", 1000,
+		"Number of upserts and deletes in each bulk write to the sink")
+	rootCmd.PersistentFlags().Int("writers", 4,
+		"Number of bulk writes to the sink in flight at once. Scanning pauses while all are busy")
+	rootCmd.PersistentFlags().Int("write-attempts", mongodb.DefaultRetryPolicy.WriteAttempts,
+		"Number of times a bulk write is attempted before its failed records are given up on")
+	rootCmd.PersistentFlags().Duration("retry-backoff", mongodb.DefaultRetryPolicy.InitialBackoff,
+		"Wait before retrying a failed sink connection or bulk write, doubled with every further retry")
+	rootCmd.PersistentFlags().Duration("retry-max-backoff", mongodb.DefaultRetryPolicy.MaxBackoff,
+		"Longest wait between retries of a failed sink connection or bulk write")
+
+	rootCmd.PersistentFlags().String("badger-dir", "",
+		"BadgerDB directory read by the dump and prefixes commands")
+
+	rootCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		viper.BindPFlag(flag.Name, flag)
+	})
+}
+
+// Returns mongodb.DefaultHistoryPrefixes as the ints of the --mongo-history-prefixes flag
+func defaultHistoryPrefixes() []int {
+	prefixes := make([]int, len(mongodb.DefaultHistoryPrefixes))
+	for i, prefix := range mongodb.DefaultHistoryPrefixes {
+		prefixes[i] = int(prefix)
+	}
+	return prefixes
 }
 
 func initConfig() {
@@ -7,8 +7,10 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	coreCmd "github.com/deso-protocol/core/cmd"
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
 	"github.com/golang/glog"
 )
 
@@ -31,11 +33,15 @@ func Run(cmd *cobra.Command, args []string) {
 	mongoNode := NewNode(mongoConfig, coreNode)
 	mongoNode.Start()
 
-	shutdownListener := make(chan os.Signal)
+	shutdownListener := make(chan os.Signal, 1)
 	signal.Notify(shutdownListener, syscall.SIGINT, syscall.SIGTERM)
 	defer func() {
+		// Stop the dumper first since it reads the core node's database
+		if !mongoNode.Stop() {
+			glog.Info("Skipping core node shutdown since the dumper may still read its database")
+			return
+		}
 		coreNode.Stop()
-		mongoNode.Stop()
 		glog.Info("Shutdown complete")
 	}()
 
@@ -47,9 +53,15 @@ func init() {
 	coreCmd.SetupRunFlags(runCmd)
 
 	// Add the mongo dumper flags
-	runCmd.PersistentFlags().String("mongo-uri", "mongodb://localhost:27017", "Mongo connection URI")
-	runCmd.PersistentFlags().String("mongo-database", "deso", "Mongo database name")
-	runCmd.PersistentFlags().String("mongo-collection", "data", "Mongo collection name")
+	runCmd.PersistentFlags().String("mongo-sync-mode", string(mongodb.SyncModeIncremental),
+		"Mongo sync mode. \"incremental\" backfills once and then only syncs changed keys, "+
+			"\"full\" rescans every key on every pass")
+	runCmd.PersistentFlags().Bool("mongo-resync", false,
+		"Discard the stored sync checkpoint and resync everything from scratch")
+	runCmd.PersistentFlags().Bool("mongo-soft-delete", false,
+		"Mark documents of deleted keys with a DeletedAt time instead of removing them")
+	runCmd.PersistentFlags().Duration("shutdown-timeout", 30*time.Second,
+		"How long to wait on shutdown for in-flight writes to finish and the checkpoint to be saved")
 
 	runCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
 		viper.BindPFlag(flag.Name, flag)
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/deso-protocol/mongodb-dumper/mongodb"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Write the JSON Schema of each typed document",
+	Long: `Writes the JSON Schema of every typed document the dumper writes, such as blocks,
+posts and profiles, to <name>.schema.json in --schema-out-dir.`,
+	Run: Schema,
+}
+
+func Schema(cmd *cobra.Command, args []string) {
+	outDir := viper.GetString("schema-out-dir")
+	cobra.CheckErr(os.MkdirAll(outDir, 0755))
+
+	for _, doc := range mongodb.DocTypes {
+		schema := mongodb.GenerateJSONSchema(doc)
+		schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+		cobra.CheckErr(err)
+
+		path := filepath.Join(outDir, reflect.TypeOf(doc).Name()+".schema.json")
+		cobra.CheckErr(ioutil.WriteFile(path, append(schemaJSON, '\n'), 0644))
+		fmt.Printf("Wrote %s\n", path)
+	}
+}
+
+func init() {
+	schemaCmd.PersistentFlags().String("schema-out-dir", "schemas",
+		"Directory the JSON Schemas are written to")
+
+	schemaCmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		viper.BindPFlag(flag.Name, flag)
+	})
+
+	rootCmd.AddCommand(schemaCmd)
+}
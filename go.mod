@@ -7,6 +7,7 @@ replace github.com/deso-protocol/core => ../core/
 require (
 	github.com/deso-protocol/core v0.0.0-00010101000000-000000000000
 	github.com/dgraph-io/badger/v3 v3.2103.0
+	github.com/dgraph-io/ristretto v0.1.0
 	github.com/fatih/structs v1.1.0
 	github.com/golang/glog v1.0.0
 	github.com/mitchellh/go-homedir v1.1.0
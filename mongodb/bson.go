@@ -0,0 +1,116 @@
+package mongodb
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// This file contains the conversion of decoded badgerDB values into BSON
+
+// RecordEncoding dictates how decoded badgerDB values are turned into sink documents
+type RecordEncoding string
+
+const (
+	// RecordEncodingBSON converts decoded values into BSON directly, keeping
+	// 64-bit integers and binary data intact
+	RecordEncodingBSON RecordEncoding = "bson"
+	// RecordEncodingJSON round-trips decoded values through JSON. Numbers
+	// become floats and binary data becomes base64 strings. Only kept for
+	// debugging and for comparing against dumps made by older versions.
+	RecordEncodingJSON RecordEncoding = "json"
+)
+
+// Converts a uint64 into an int64 if it fits, otherwise into a Decimal128
+func uint64ToBSON(val uint64) interface{} {
+	if val <= math.MaxInt64 {
+		return int64(val)
+	}
+	dec, _ := primitive.ParseDecimal128(strconv.FormatUint(val, 10))
+	return dec
+}
+
+// Converts a big.Int into an int64 if it fits, otherwise into a Decimal128.
+// Values too large for a Decimal128 are kept as decimal strings.
+func bigIntToBSON(val *big.Int) interface{} {
+	if val == nil {
+		return nil
+	}
+	if val.IsInt64() {
+		return val.Int64()
+	}
+	dec, err := primitive.ParseDecimal128(val.String())
+	if err != nil {
+		return val.String()
+	}
+	return dec
+}
+
+// Converts a json.Number into an int64, a Decimal128 or a float64
+func jsonNumberToBSON(val json.Number) interface{} {
+	if intVal, err := strconv.ParseInt(string(val), 10, 64); err == nil {
+		return intVal
+	}
+	if uintVal, err := strconv.ParseUint(string(val), 10, 64); err == nil {
+		return uint64ToBSON(uintVal)
+	}
+	if dec, err := primitive.ParseDecimal128(string(val)); err == nil {
+		return dec
+	}
+	floatVal, _ := val.Float64()
+	return floatVal
+}
+
+// Recursively converts val into a value the BSON encoder stores without losing
+// precision. Maps become bson.M, unsigned integers that don't fit an int64 become
+// Decimal128, and values of types the encoder can't handle natively are converted
+// through JSON with their numbers kept exact.
+func toBSONValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil, bool, string, int, int8, int16, int32, int64, uint8, uint16, uint32,
+		float32, float64, []byte, time.Time, primitive.Decimal128, primitive.Binary:
+		return v
+	case uint64:
+		return uint64ToBSON(v)
+	case uint:
+		return uint64ToBSON(uint64(v))
+	case *big.Int:
+		return bigIntToBSON(v)
+	case json.Number:
+		return jsonNumberToBSON(v)
+	case map[string]interface{}:
+		doc := make(bson.M, len(v))
+		for key, subVal := range v {
+			doc[key] = toBSONValue(subVal)
+		}
+		return doc
+	case bson.M:
+		return toBSONValue(map[string]interface{}(v))
+	case []interface{}:
+		arr := make(bson.A, len(v))
+		for i, subVal := range v {
+			arr[i] = toBSONValue(subVal)
+		}
+		return arr
+	}
+
+	// Anything else, e.g. nested structs or typed slices and maps, goes
+	// through JSON so that it keeps the same shape as in the JSON encoding
+	valJSON, err := json.Marshal(val)
+	if err != nil {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(valJSON))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err = decoder.Decode(&decoded); err != nil {
+		return nil
+	}
+	return toBSONValue(decoded)
+}
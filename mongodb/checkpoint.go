@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/deso-protocol/core/lib"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// This file contains the sync checkpoint stored in the sink
+
+// SyncCheckpoint records how far the SyncingService got syncing badgerDB
+// into the sink so that a restart can resume instead of rescanning from zero
+type SyncCheckpoint struct {
+	// LastSyncedKey holds the hex encoded badgerDB key of the last key written
+	// by the full scan in progress. Empty when no full scan is in progress.
+	LastSyncedKey string `bson:"LastSyncedKey" json:"LastSyncedKey"`
+	// ScanReadTs holds the badgerDB read timestamp the full scan in progress
+	// started at. A resumed scan keeps the timestamp of the original scan.
+	ScanReadTs uint64 `bson:"ScanReadTs" json:"ScanReadTs"`
+	// BackfillComplete is true once a full scan has reached the end of badgerDB
+	BackfillComplete bool `bson:"BackfillComplete" json:"BackfillComplete"`
+	// BadgerReadTs holds the badgerDB read timestamp the checkpoint covers.
+	// Once BackfillComplete is true every key with a version at or below
+	// it has been synced to the sink.
+	BadgerReadTs uint64 `bson:"BadgerReadTs" json:"BadgerReadTs"`
+	// TipBlockHash and TipHeight describe the best DeSo chain tip at the
+	// time the checkpoint was taken
+	TipBlockHash string `bson:"TipBlockHash" json:"TipBlockHash"`
+	TipHeight    uint64 `bson:"TipHeight" json:"TipHeight"`
+	// UpdatedAt holds the wall-clock time the checkpoint was written
+	UpdatedAt time.Time `bson:"UpdatedAt" json:"UpdatedAt"`
+	// Health holds the health of the sync when the checkpoint was written
+	Health *SyncHealth `bson:"Health,omitempty" json:"Health,omitempty"`
+}
+
+// Returns the decoded LastSyncedKey or nil if there is none
+func (checkpoint *SyncCheckpoint) lastSyncedKeyBytes() []byte {
+	if checkpoint == nil || checkpoint.LastSyncedKey == "" {
+		return nil
+	}
+	key, err := hex.DecodeString(checkpoint.LastSyncedKey)
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+// Reads the best DeSo chain tip hash and height from badgerDB
+func getChainTip(txn *badger.Txn) (string, uint64) {
+	item, err := txn.Get([]byte{3}) // _KeyBestDeSoBlockHash
+	if err != nil {
+		return "", 0
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return "", 0
+	}
+	var tipHash lib.BlockHash
+	copy(tipHash[:], val)
+
+	item, err = txn.Get(append([]byte{0}, tipHash[:]...)) // _PrefixBlockHashToBlock
+	if err != nil {
+		return tipHash.String(), 0
+	}
+	val, err = item.ValueCopy(nil)
+	if err != nil {
+		return tipHash.String(), 0
+	}
+	block := lib.NewMessage(lib.MsgTypeBlock).(*lib.MsgDeSoBlock)
+	if err = block.FromBytes(val); err != nil || block.Header == nil {
+		return tipHash.String(), 0
+	}
+
+	return tipHash.String(), block.Header.Height
+}
+
+// Writes checkpoint, stamped with the current sync health, to the sink,
+// replacing any previous checkpoint
+func (syncSrv *SyncingService) saveCheckpoint(checkpoint *SyncCheckpoint) {
+	checkpoint.UpdatedAt = time.Now()
+	health := syncSrv.Health()
+	checkpoint.Health = &health
+	if err := syncSrv.Sink.SaveCheckpoint(checkpoint); err != nil {
+		fmt.Printf("Failed to save sync checkpoint: %v\n", err)
+	}
+}
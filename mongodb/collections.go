@@ -0,0 +1,108 @@
+package mongodb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file contains the mapping of badgerDB key prefixes to mongoDB collections
+
+// CollectionLayout dictates how the MongoSink spreads documents across collections
+type CollectionLayout string
+
+const (
+	// CollectionLayoutPerPrefix writes each badgerDB key prefix to its own collection
+	CollectionLayoutPerPrefix CollectionLayout = "per-prefix"
+	// CollectionLayoutSingle writes every document to one collection. This is
+	// the legacy layout, where only the BadgerKeyPrefix field tells records apart.
+	CollectionLayoutSingle CollectionLayout = "single"
+)
+
+// DefaultPrefixCollections maps badgerDB key prefixes to the collection their
+// documents are written to by the per-prefix layout. Prefixes missing from the
+// map are written to the default collection.
+var DefaultPrefixCollections = map[byte]string{
+	0:  "blocks",                  // _PrefixBlockHashToBlock
+	1:  "block_nodes",             // _PrefixHeightHashToNodeInfo
+	2:  "bitcoin_block_nodes",     // _PrefixBitcoinHeightHashToNodeInfo
+	3:  "chain_state",             // _KeyBestDeSoBlockHash
+	4:  "chain_state",             // _KeyBestBitcoinHeaderHash
+	5:  "utxo_entries",            // _PrefixUtxoKeyToUtxoEntry
+	6:  "utxo_positions",          // _PrefixPositionToUtxoKey
+	7:  "public_key_utxos",        // _PrefixPubKeyUtxoKey
+	8:  "chain_state",             // _KeyUtxoNumEntries
+	9:  "utxo_operations",         // _PrefixBlockHashToUtxoOperations
+	10: "chain_state",             // _KeyNanosPurchased
+	11: "bitcoin_burn_txids",      // _PrefixBitcoinBurnTxIDs
+	12: "messages",                // _PrefixPublicKeyTimestampToPrivateMessage
+	13: "chain_state",             // _KeyAccountData
+	14: "chain_state",             // _KeyTransactionIndexTip
+	15: "transaction_metadata",    // _PrefixTransactionIDToMetadata
+	16: "public_key_transactions", // _PrefixPublicKeyIndexToTransactionIDs
+	17: "posts",                   // _PrefixPostHashToPostEntry
+	18: "poster_posts",            // _PrefixPosterPublicKeyPostHash
+	19: "timestamp_posts",         // _PrefixTstampNanosPostHash
+	20: "creator_bps_posts",       // _PrefixCreatorBpsPostHash
+	21: "multiple_bps_posts",      // _PrefixMultipleBpsPostHash
+	22: "comments",                // _PrefixCommentParentStakeIDToPostHash
+	23: "profiles",                // _PrefixPKIDToProfileEntry
+	24: "profile_stakes",          // _PrefixProfileStakeToProfilePubKey
+	25: "usernames",               // _PrefixProfileUsernameToPKID
+	26: "stake_ids",               // _PrefixStakeIDTypeAmountStakeIDIndex
+	27: "chain_state",             // _KeyUSDCentsPerBitcoinExchangeRate
+	28: "follows",                 // _PrefixFollowerPKIDToFollowedPKID
+	29: "followers",               // _PrefixFollowedPKIDToFollowerPKID
+	30: "likes",                   // _PrefixLikerPubKeyToLikedPostHash
+	31: "post_likes",              // _PrefixLikedPostHashToLikerPubKey
+	32: "creator_locked_nanos",    // _PrefixCreatorDeSoLockedNanosCreatorPKID
+	33: "balance_entries",         // _PrefixHODLerPKIDCreatorPKIDToBalanceEntry
+	34: "creator_balance_entries", // _PrefixCreatorPKIDHODLerPKIDToBalanceEntry
+	35: "poster_timestamp_posts",  // _PrefixPosterPublicKeyTimestampPostHash
+	36: "public_key_pkids",        // _PrefixPublicKeyToPKID
+	37: "pkid_public_keys",        // _PrefixPKIDToPublicKey
+	38: "mempool_txns",            // _PrefixMempoolTxnHashToMsgDeSoTxn
+	39: "reposts",                 // _PrefixReposterPubKeyRepostedPostHashToRepostPostHash
+	40: "chain_state",             // _KeyGlobalParams
+	41: "diamonds_received",       // _PrefixDiamondReceiverPKIDDiamondSenderPKIDPostHash
+	42: "public_key_next_indexes", // _PrefixPublicKeyToNextIndex
+	43: "diamonds_given",          // _PrefixDiamondSenderPKIDDiamondReceiverPKIDPostHash
+	44: "forbidden_public_keys",   // _PrefixForbiddenBlockSignaturePubKeys
+	45: "post_reposters",          // _PrefixRepostedPostHashReposterPubKey
+	46: "post_quote_reposts",      // _PrefixRepostedPostHashReposterPubKeyRepostPostHash
+	47: "nft_entries",             // _PrefixPostHashSerialNumberToNFTEntry
+	48: "owner_nft_entries",       // _PrefixPKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry
+	49: "nft_bids",                // _PrefixPostHashSerialNumberBidNanosBidderPKID
+	50: "bidder_nft_bids",         // _PrefixBidderPKIDPostHashSerialNumberToBidNanos
+	51: "deso_balances",           // _PrefixPublicKeyToDeSoBalanceNanos
+	52: "block_rewards",           // _PrefixPublicKeyBlockHashToBlockReward
+	53: "nft_accepted_bids",       // _PrefixPostHashSerialNumberToAcceptedBidEntries
+	54: "derived_keys",            // _PrefixAuthorizeDerivedKey
+	55: "dao_balances",            // _PrefixHODLerPKIDCreatorPKIDToDAOCoinBalanceEntry
+	56: "dao_creator_balances",    // _PrefixCreatorPKIDHODLerPKIDToDAOCoinBalanceEntry
+	57: "messaging_groups",        // _PrefixMessagingGroupEntriesByOwnerPubKeyAndGroupKeyName
+	58: "messaging_group_members", // _PrefixMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey
+}
+
+// Returns DefaultPrefixCollections with the given overrides applied. Each
+// override has the form "<prefix>=<collection>", e.g. "17=posts".
+func ParsePrefixCollections(overrides []string) (map[byte]string, error) {
+	prefixCollections := make(map[byte]string, len(DefaultPrefixCollections))
+	for prefix, collection := range DefaultPrefixCollections {
+		prefixCollections[prefix] = collection
+	}
+
+	for _, override := range overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("Invalid prefix collection mapping %q, expected <prefix>=<collection>", override)
+		}
+		prefix, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid prefix in collection mapping %q: %v", override, err)
+		}
+		prefixCollections[byte(prefix)] = strings.TrimSpace(parts[1])
+	}
+
+	return prefixCollections, nil
+}
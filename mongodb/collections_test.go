@@ -0,0 +1,28 @@
+package mongodb
+
+import "testing"
+
+func TestParsePrefixCollections(t *testing.T) {
+	prefixCollections, err := ParsePrefixCollections([]string{"17=blog_posts", " 200 = extra "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := prefixCollections[17]; got != "blog_posts" {
+		t.Errorf("Prefix 17 maps to %q, want blog_posts", got)
+	}
+	if got := prefixCollections[200]; got != "extra" {
+		t.Errorf("Prefix 200 maps to %q, want extra", got)
+	}
+	if got := prefixCollections[5]; got != DefaultPrefixCollections[5] {
+		t.Errorf("Prefix 5 maps to %q, want the default %q", got, DefaultPrefixCollections[5])
+	}
+	if DefaultPrefixCollections[17] != "posts" {
+		t.Error("Overrides changed DefaultPrefixCollections")
+	}
+
+	for _, override := range []string{"17", "17=", "17= ", "posts=17", "256=posts", "-1=posts"} {
+		if _, err := ParsePrefixCollections([]string{override}); err == nil {
+			t.Errorf("Accepted invalid override %q", override)
+		}
+	}
+}
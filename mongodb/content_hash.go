@@ -0,0 +1,156 @@
+package mongodb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/dgraph-io/badger/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// This file contains the hashing of record contents, used to skip writing
+// records that didn't change since they were last written to the sink
+
+// Field holding the hex encoded content hash of a document in the MongoSink,
+// used to only stamp the metadata of documents whose content changed
+const contentHashField = "ContentHash"
+
+// syncMetadataFields are the document fields describing a write rather than
+// the record's content, which are ignored when comparing or hashing contents
+var syncMetadataFields = []string{"_id", badgerKeyField, badgerVersionField, tipHeightField,
+	tipBlockHashField, firstSeenField, lastChangedField, schemaVersionField,
+	validFromHeightField, deletedAtField, legacyTimeField, contentHashField}
+
+// HashCache is a local badgerDB database holding the content hash of every record
+// last written to the sink, keyed by the record's badgerDB key
+type HashCache struct {
+	db *badger.DB
+}
+
+// Opens the HashCache stored in dir, creating it if it doesn't exist
+func OpenHashCache(dir string) (*HashCache, error) {
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open hash cache in %v: %v", dir, err)
+	}
+	return &HashCache{db: db}, nil
+}
+
+// Closes the cache's database
+func (cache *HashCache) Close() error {
+	return cache.db.Close()
+}
+
+// Removes every hash, so that every record is written again
+func (cache *HashCache) Clear() error {
+	return cache.db.DropAll()
+}
+
+// Returns true if hash is the cached hash of the record for key
+func (cache *HashCache) unchanged(key []byte, hash []byte) bool {
+	same := false
+	cache.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			same = bytes.Equal(val, hash)
+			return nil
+		})
+	})
+	return same
+}
+
+// Stores the hashes of written records, keyed by badgerDB key, and removes
+// the hashes of the removed keys
+func (cache *HashCache) update(hashes map[string][]byte, removed [][]byte) error {
+	batch := cache.db.NewWriteBatch()
+	defer batch.Cancel()
+	for key, hash := range hashes {
+		if err := batch.Set([]byte(key), hash); err != nil {
+			return err
+		}
+	}
+	for _, key := range removed {
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Returns the hash of the content of doc, which ignores sync metadata and
+// changes whenever DocSchemaVersion is bumped
+func contentHash(doc map[string]interface{}) ([]byte, error) {
+	content, err := canonicalContent(doc)
+	if err != nil {
+		return nil, err
+	}
+	var version [8]byte
+	binary.BigEndian.PutUint64(version[:], DocSchemaVersion)
+
+	hasher := sha256.New()
+	hasher.Write(version[:])
+	hasher.Write(content)
+	return hasher.Sum(nil), nil
+}
+
+// Returns the BSON encoding of doc without its sync metadata and with the fields
+// of every embedded document sorted, which is the same for documents with equal content
+func canonicalContent(doc map[string]interface{}) ([]byte, error) {
+	// Round trip through BSON so that values have the types read back from mongoDB
+	docBSON, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var decoded bson.M
+	if err := bson.Unmarshal(docBSON, &decoded); err != nil {
+		return nil, err
+	}
+	for _, field := range syncMetadataFields {
+		delete(decoded, field)
+	}
+	return bson.Marshal(sortedValue(decoded))
+}
+
+// Converts embedded documents in val into documents with sorted fields
+func sortedValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case bson.M:
+		return sortedDocument(v)
+	case map[string]interface{}:
+		return sortedDocument(v)
+	case bson.D:
+		docMap := make(map[string]interface{}, len(v))
+		for _, elem := range v {
+			docMap[elem.Key] = elem.Value
+		}
+		return sortedDocument(docMap)
+	case bson.A:
+		arr := make(bson.A, len(v))
+		for i, elem := range v {
+			arr[i] = sortedValue(elem)
+		}
+		return arr
+	}
+	return val
+}
+
+// Returns the fields of doc sorted by name
+func sortedDocument(doc map[string]interface{}) bson.D {
+	fields := make([]string, 0, len(doc))
+	for field := range doc {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	sorted := make(bson.D, 0, len(fields))
+	for _, field := range fields {
+		sorted = append(sorted, bson.E{Key: field, Value: sortedValue(doc[field])})
+	}
+	return sorted
+}
@@ -0,0 +1,52 @@
+package mongodb
+
+import (
+	"bytes"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCanonicalContent(t *testing.T) {
+	doc := map[string]interface{}{
+		"Body":             "gm",
+		"LikeCount":        uint64(3),
+		"PostExtraData":    map[string]interface{}{"a": "1", "b": "2"},
+		"RecloutedPosts":   []interface{}{map[string]interface{}{"x": 1, "y": 2}},
+		tipHeightField:     uint64(100),
+		lastChangedField:   "2021-01-01",
+		badgerVersionField: uint64(7),
+	}
+	// The same content as read back from mongoDB, with other sync metadata
+	readBack := bson.M{
+		"_id":              "05ab",
+		"Body":             "gm",
+		"LikeCount":        int64(3),
+		"PostExtraData":    bson.D{{Key: "b", Value: "2"}, {Key: "a", Value: "1"}},
+		"RecloutedPosts":   bson.A{bson.D{{Key: "y", Value: int32(2)}, {Key: "x", Value: int32(1)}}},
+		tipHeightField:     int64(200),
+		contentHashField:   "deadbeef",
+		badgerVersionField: int64(9),
+	}
+
+	content, err := canonicalContent(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readBackContent, err := canonicalContent(readBack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(content, readBackContent) {
+		t.Error("Documents with equal content have different canonical contents")
+	}
+
+	doc["Body"] = "gn"
+	changedContent, err := canonicalContent(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(content, changedContent) {
+		t.Error("Documents with different content have equal canonical contents")
+	}
+}
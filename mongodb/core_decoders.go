@@ -0,0 +1,1105 @@
+package mongodb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/deso-protocol/core/lib"
+	"github.com/fatih/structs"
+)
+
+// This file contains the decoders for every badgerDB key prefix defined by core
+
+func init() {
+	RegisterDecoder(NewDecoder(0, "_PrefixBlockHashToBlock", decodeBlockHashToBlock))
+	RegisterDecoder(NewDecoder(1, "_PrefixHeightHashToNodeInfo", decodeHeightHashToNodeInfo))
+	RegisterDecoder(NewDecoder(2, "_PrefixBitcoinHeightHashToNodeInfo", decodeBitcoinHeightHashToNodeInfo))
+	RegisterDecoder(NewDecoder(3, "_KeyBestDeSoBlockHash", decodeBestDeSoBlockHash))
+	RegisterDecoder(NewDecoder(4, "_KeyBestBitcoinHeaderHash", decodeBestBitcoinHeaderHash))
+	RegisterDecoder(NewDecoder(5, "_PrefixUtxoKeyToUtxoEntry", decodeUtxoKeyToUtxoEntry))
+	RegisterDecoder(NewDecoder(6, "_PrefixPositionToUtxoKey", decodePositionToUtxoKey))
+	RegisterDecoder(NewDecoder(7, "_PrefixPubKeyUtxoKey", decodePubKeyUtxoKey))
+	RegisterDecoder(NewDecoder(8, "_KeyUtxoNumEntries", decodeUtxoNumEntries))
+	RegisterDecoder(NewDecoder(9, "_PrefixBlockHashToUtxoOperations", decodeBlockHashToUtxoOperations))
+	RegisterDecoder(NewDecoder(10, "_KeyNanosPurchased", decodeNanosPurchased))
+	RegisterDecoder(NewDecoder(11, "_PrefixBitcoinBurnTxIDs", decodeBitcoinBurnTxIDs))
+	RegisterDecoder(NewDecoder(12, "_PrefixPublicKeyTimestampToPrivateMessage", decodePublicKeyTimestampToPrivateMessage))
+	RegisterDecoder(NewDecoder(13, "_KeyAccountData", decodeAccountData))
+	RegisterDecoder(NewDecoder(14, "_KeyTransactionIndexTip", decodeTransactionIndexTip))
+	RegisterDecoder(NewDecoder(15, "_PrefixTransactionIDToMetadata", decodeTransactionIDToMetadata))
+	RegisterDecoder(NewDecoder(16, "_PrefixPublicKeyIndexToTransactionIDs", decodePublicKeyIndexToTransactionIDs))
+	RegisterDecoder(NewDecoder(17, "_PrefixPostHashToPostEntry", decodePostHashToPostEntry))
+	RegisterDecoder(NewDecoder(18, "_PrefixPosterPublicKeyPostHash", decodePosterPublicKeyPostHash))
+	RegisterDecoder(NewDecoder(19, "_PrefixTstampNanosPostHash", decodeTstampNanosPostHash))
+	RegisterDecoder(NewDecoder(20, "_PrefixCreatorBpsPostHash", decodeCreatorBpsPostHash))
+	RegisterDecoder(NewDecoder(21, "_PrefixMultipleBpsPostHash", decodeMultipleBpsPostHash))
+	RegisterDecoder(NewDecoder(22, "_PrefixCommentParentStakeIDToPostHash", decodeCommentParentStakeIDToPostHash))
+	RegisterDecoder(NewDecoder(23, "_PrefixPKIDToProfileEntry", decodePKIDToProfileEntry))
+	RegisterDecoder(NewDecoder(24, "_PrefixProfileStakeToProfilePubKey", decodeProfileStakeToProfilePubKey))
+	RegisterDecoder(NewDecoder(25, "_PrefixProfileUsernameToPKID", decodeProfileUsernameToPKID))
+	RegisterDecoder(NewDecoder(26, "_PrefixStakeIDTypeAmountStakeIDIndex", decodeStakeIDTypeAmountStakeIDIndex))
+	RegisterDecoder(NewDecoder(27, "_KeyUSDCentsPerBitcoinExchangeRate", decodeUSDCentsPerBitcoinExchangeRate))
+	RegisterDecoder(NewDecoder(28, "_PrefixFollowerPKIDToFollowedPKID", decodeFollowerPKIDToFollowedPKID))
+	RegisterDecoder(NewDecoder(29, "_PrefixFollowedPKIDToFollowerPKID", decodeFollowedPKIDToFollowerPKID))
+	RegisterDecoder(NewDecoder(30, "_PrefixLikerPubKeyToLikedPostHash", decodeLikerPubKeyToLikedPostHash))
+	RegisterDecoder(NewDecoder(31, "_PrefixLikedPostHashToLikerPubKey", decodeLikedPostHashToLikerPubKey))
+	RegisterDecoder(NewDecoder(32, "_PrefixCreatorDeSoLockedNanosCreatorPKID", decodeCreatorDeSoLockedNanosCreatorPKID))
+	RegisterDecoder(NewDecoder(33, "_PrefixHODLerPKIDCreatorPKIDToBalanceEntry", decodeHODLerPKIDCreatorPKIDToBalanceEntry))
+	RegisterDecoder(NewDecoder(34, "_PrefixCreatorPKIDHODLerPKIDToBalanceEntry", decodeCreatorPKIDHODLerPKIDToBalanceEntry))
+	RegisterDecoder(NewDecoder(35, "_PrefixPosterPublicKeyTimestampPostHash", decodePosterPublicKeyTimestampPostHash))
+	RegisterDecoder(NewDecoder(36, "_PrefixPublicKeyToPKID", decodePublicKeyToPKID))
+	RegisterDecoder(NewDecoder(37, "_PrefixPKIDToPublicKey", decodePKIDToPublicKey))
+	RegisterDecoder(NewDecoder(38, "_PrefixMempoolTxnHashToMsgDeSoTxn", decodeMempoolTxnHashToMsgDeSoTxn))
+	RegisterDecoder(NewDecoder(39, "_PrefixReposterPubKeyRepostedPostHashToRepostPostHash", decodeReposterPubKeyRepostedPostHashToRepostPostHash))
+	RegisterDecoder(NewDecoder(40, "_KeyGlobalParams", decodeGlobalParams))
+	RegisterDecoder(NewDecoder(41, "_PrefixDiamondReceiverPKIDDiamondSenderPKIDPostHash", decodeDiamondReceiverPKIDDiamondSenderPKIDPostHash))
+	RegisterDecoder(NewDecoder(42, "_PrefixPublicKeyToNextIndex", decodePublicKeyToNextIndex))
+	RegisterDecoder(NewDecoder(43, "_PrefixDiamondSenderPKIDDiamondReceiverPKIDPostHash", decodeDiamondSenderPKIDDiamondReceiverPKIDPostHash))
+	RegisterDecoder(NewDecoder(44, "_PrefixForbiddenBlockSignaturePubKeys", decodeForbiddenBlockSignaturePubKeys))
+	RegisterDecoder(NewDecoder(45, "_PrefixRepostedPostHashReposterPubKey", decodeRepostedPostHashReposterPubKey))
+	RegisterDecoder(NewDecoder(46, "_PrefixRepostedPostHashReposterPubKeyRepostPostHash", decodeRepostedPostHashReposterPubKeyRepostPostHash))
+	RegisterDecoder(NewDecoder(47, "_PrefixPostHashSerialNumberToNFTEntry", decodePostHashSerialNumberToNFTEntry))
+	RegisterDecoder(NewDecoder(48, "_PrefixPKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry", decodePKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry))
+	RegisterDecoder(NewDecoder(49, "_PrefixPostHashSerialNumberBidNanosBidderPKID", decodePostHashSerialNumberBidNanosBidderPKID))
+	RegisterDecoder(NewDecoder(50, "_PrefixBidderPKIDPostHashSerialNumberToBidNanos", decodeBidderPKIDPostHashSerialNumberToBidNanos))
+	RegisterDecoder(NewDecoder(51, "_PrefixPublicKeyToDeSoBalanceNanos", decodePublicKeyToDeSoBalanceNanos))
+	RegisterDecoder(NewDecoder(52, "_PrefixPublicKeyBlockHashToBlockReward", decodePublicKeyBlockHashToBlockReward))
+	RegisterDecoder(NewDecoder(53, "_PrefixPostHashSerialNumberToAcceptedBidEntries", decodePostHashSerialNumberToAcceptedBidEntries))
+	RegisterDecoder(NewDecoder(54, "_PrefixAuthorizeDerivedKey", decodeAuthorizeDerivedKey))
+	RegisterDecoder(NewDecoder(55, "_PrefixHODLerPKIDCreatorPKIDToDAOCoinBalanceEntry", decodeHODLerPKIDCreatorPKIDToDAOCoinBalanceEntry))
+	RegisterDecoder(NewDecoder(56, "_PrefixCreatorPKIDHODLerPKIDToDAOCoinBalanceEntry", decodeCreatorPKIDHODLerPKIDToDAOCoinBalanceEntry))
+	RegisterDecoder(NewDecoder(57, "_PrefixMessagingGroupEntriesByOwnerPubKeyAndGroupKeyName", decodeMessagingGroupEntriesByOwnerPubKeyAndGroupKeyName))
+	RegisterDecoder(NewDecoder(58, "_PrefixMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey", decodeMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey))
+}
+
+// Decodes a _PrefixBlockHashToBlock key/value pair
+func decodeBlockHashToBlock(key []byte, val []byte) (map[string]interface{}, error) {
+	blockRet := lib.NewMessage(lib.MsgTypeBlock).(*lib.MsgDeSoBlock)
+	var blockHash lib.BlockHash
+
+	err := blockRet.FromBytes(val)
+	if err != nil {
+		return nil, err
+	}
+	copy(blockHash[:], key[1:])
+
+	doc := BlockDoc{
+		DocMeta:   newDocMeta("A deso block and its corresponding blockhash.", "_PrefixBlockHashToBlock:0"),
+		BlockHash: blockHash.String(),
+		Header:    newBlockHeaderDoc(blockRet.Header),
+	}
+	for _, txn := range blockRet.Txns {
+		if txn != nil {
+			doc.Txns = append(doc.Txns, newTxnDoc(txn))
+		}
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixHeightHashToNodeInfo key/value pair
+func decodeHeightHashToNodeInfo(key []byte, val []byte) (map[string]interface{}, error) {
+	BN, err := lib.DeserializeBlockNode(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return docToMap(newBlockNodeDoc(BN, "A block node in the deso blockchain graph.", "_PrefixHeightHashToNodeInfo:1")), nil
+}
+
+// Decodes a _PrefixBitcoinHeightHashToNodeInfo key/value pair
+func decodeBitcoinHeightHashToNodeInfo(key []byte, val []byte) (map[string]interface{}, error) {
+	BN, err := lib.DeserializeBlockNode(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return docToMap(newBlockNodeDoc(BN, "A block node in the bitcoin blockchain graph.", "_PrefixBitcoinHeightHashToNodeInfo:2")), nil
+}
+
+// Decodes a _KeyBestDeSoBlockHash key/value pair
+func decodeBestDeSoBlockHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var ret lib.BlockHash
+	copy(ret[:], val)
+
+	docMap := map[string]interface{}{
+		"Hash":            ret.String(),
+		"MongoMeta":       "The hash of the front of the best DeSo Chain.",
+		"BadgerKeyPrefix": "_KeyBestDeSoBlockHash:3",
+	}
+
+	return docMap, nil
+}
+
+// Decodes a _KeyBestBitcoinHeaderHash key/value pair
+func decodeBestBitcoinHeaderHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var ret lib.BlockHash
+	copy(ret[:], val)
+
+	docMap := map[string]interface{}{
+		"Hash":            ret.String(),
+		"MongoMeta":       "The hash of the front of the best Bitcoin Chain.",
+		"BadgerKeyPrefix": "_KeyBestBitcoinHeaderHash:4",
+	}
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixUtxoKeyToUtxoEntry key/value pair
+func decodeUtxoKeyToUtxoEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	var ret lib.UtxoEntry
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	err := dec.Decode(&ret)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := UtxoDoc{
+		DocMeta:     newDocMeta("A UTXO Entry.", "_PrefixUtxoKeyToUtxoEntry:5"),
+		AmountNanos: ret.AmountNanos,
+		PublicKey:   lib.PkToStringBoth(ret.PublicKey),
+		BlockHeight: ret.BlockHeight,
+		UtxoType:    ret.UtxoType.String(),
+	}
+	if ret.UtxoKey != nil {
+		doc.UtxoKey = &UtxoKeyDoc{TxID: ret.UtxoKey.TxID.String(), Index: ret.UtxoKey.Index}
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixPositionToUtxoKey key/value pair
+func decodePositionToUtxoKey(key []byte, val []byte) (map[string]interface{}, error) {
+	var ret lib.UtxoKey
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	err := dec.Decode(&ret)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := structs.Map(ret)
+	SimplifyMap(&docMap)
+	docMap["MongoMeta"] = "A UTXO Key."
+	docMap["BadgerKeyPrefix"] = "_PrefixPositionToUtxoKey:6"
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPubKeyUtxoKey key/value pair
+func decodePubKeyUtxoKey(key []byte, val []byte) (map[string]interface{}, error) {
+	var ret lib.UtxoKey
+	var newHash lib.BlockHash
+	pubKey := key[1:34]
+	copy(newHash[:], key[34:66])
+	index := binary.BigEndian.Uint32(key[66:])
+
+	ret.TxID = newHash
+	ret.Index = index
+
+	docMap := structs.Map(ret)
+	docMap["PublicKey"] = pubKey
+	SimplifyMap(&docMap)
+	docMap["MongoMeta"] = "Public key and UTXO key."
+	docMap["BadgerKeyPrefix"] = "_PrefixPubKeyUtxoKey:7"
+
+	return docMap, nil
+}
+
+// Decodes a _KeyUtxoNumEntries key/value pair
+func decodeUtxoNumEntries(key []byte, val []byte) (map[string]interface{}, error) {
+	numEntries := lib.DecodeUint64(val)
+
+	docMap := map[string]interface{}{
+		"UTXOs":           numEntries,
+		"MongoMeta":       "The number of utxo entries in the database.",
+		"BadgerKeyPrefix": "_KeyUtxoNumEntries:8",
+	}
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixBlockHashToUtxoOperations key/value pair
+func decodeBlockHashToUtxoOperations(key []byte, val []byte) (map[string]interface{}, error) {
+	var blockHash lib.BlockHash
+	copy(blockHash[:], key[1:])
+
+	var utxoOpsForBlock [][]*lib.UtxoOperation
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	err := dec.Decode(&utxoOpsForBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	// One entry per transaction in the block, in block order
+	txns := make([]interface{}, len(utxoOpsForBlock))
+	numUtxoOps := 0
+	for txnIndex, utxoOps := range utxoOpsForBlock {
+		ops := make([]interface{}, 0, len(utxoOps))
+		for _, utxoOp := range utxoOps {
+			if utxoOp == nil {
+				continue
+			}
+			ops = append(ops, UtxoOperationToMap(utxoOp))
+		}
+		numUtxoOps += len(ops)
+		txns[txnIndex] = map[string]interface{}{
+			"TxnIndexInBlock": txnIndex,
+			"UtxoOperations":  ops,
+		}
+	}
+
+	docMap := map[string]interface{}{
+		"BlockHash":         blockHash,
+		"Transactions":      txns,
+		"NumTransactions":   len(txns),
+		"NumUtxoOperations": numUtxoOps,
+		"MongoMeta":         "The operations each transaction in a block performed, used to undo the block when it's disconnected.",
+		"BadgerKeyPrefix":   "_PrefixBlockHashToUtxoOperations:9",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _KeyNanosPurchased key/value pair
+func decodeNanosPurchased(key []byte, val []byte) (map[string]interface{}, error) {
+	var nanosPurchased uint64
+	nanosPurchased = lib.DecodeUint64(val)
+
+	docMap := map[string]interface{}{
+		"Nanos":           nanosPurchased,
+		"MongoMeta":       "The number of nanos purchased thus far.",
+		"BadgerKeyPrefix": "_KeyNanosPurchased:10",
+	}
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixBitcoinBurnTxIDs key/value pair
+func decodeBitcoinBurnTxIDs(key []byte, val []byte) (map[string]interface{}, error) {
+	var newHash lib.BlockHash
+	copy(newHash[:], key[1:])
+
+	docMap := map[string]interface{}{
+		"TxID":            newHash,
+		"MongoMeta":       "A processed bitcoin transaction.",
+		"BadgerKeyPrefix": "_PrefixBitcoinBurnTxIDs:11",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPublicKeyTimestampToPrivateMessage key/value pair
+func decodePublicKeyTimestampToPrivateMessage(key []byte, val []byte) (map[string]interface{}, error) {
+	var ret lib.MessageEntry
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	err := dec.Decode(&ret)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := structs.Map(ret)
+	SimplifyMap(&docMap)
+	docMap["MongoMeta"] = "An encrypted message between two users."
+	docMap["BadgerKeyPrefix"] = "_PrefixPublicKeyTimestampToPrivateMessage:12"
+
+	return docMap, nil
+}
+
+// Decodes a _KeyAccountData key/value pair
+func decodeAccountData(key []byte, val []byte) (map[string]interface{}, error) {
+	docMap := map[string]interface{}{
+		"BadgerKeyPrefix": "_KeyAccountData:13",
+	}
+
+	return docMap, nil
+
+	// TODO: Fix with proper data in the future
+	//var accountData AccountData
+	//_ := json.Unmarshal(val, &accountData)
+}
+
+// Decodes a _KeyTransactionIndexTip key/value pair
+func decodeTransactionIndexTip(key []byte, val []byte) (map[string]interface{}, error) {
+	var ret lib.BlockHash
+	copy(ret[:], val)
+
+	docMap := map[string]interface{}{
+		"Hash": ret.String(),
+		"MongoMeta": "The transaction index supports the block explorer and is only created when a node is run with --txindex." +
+			"It uses its own separate blockchain data structure to create the index, and this is the tip of that blockchain.",
+		"BadgerKeyPrefix": "_KeyTransactionIndexTip:14",
+	}
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixTransactionIDToMetadata key/value pair
+func decodeTransactionIDToMetadata(key []byte, val []byte) (map[string]interface{}, error) {
+	var ret lib.TransactionMetadata
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	err := dec.Decode(&ret)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := structs.Map(ret)
+	SimplifyMap(&docMap)
+	docMap["MongoMeta"] = "The transaction metadata for a particular transaction ID."
+	docMap["BadgerKeyPrefix"] = "_PrefixTransactionIDToMetadata:15"
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPublicKeyIndexToTransactionIDs key/value pair
+func decodePublicKeyIndexToTransactionIDs(key []byte, val []byte) (map[string]interface{}, error) {
+	// <prefix, public key [33]byte, index uint32> -> <txid BlockHash>
+	if len(key) != 38 {
+		return nil, fmt.Errorf("Invalid key length %d, expected 38", len(key))
+	}
+	var txID lib.BlockHash
+	copy(txID[:], val)
+
+	docMap := map[string]interface{}{
+		"PublicKey":       key[1:34],
+		"Index":           binary.BigEndian.Uint32(key[34:38]),
+		"TxID":            txID,
+		"MongoMeta":       "The ID of the n-th transaction involving a public key, in the order the transactions were indexed.",
+		"BadgerKeyPrefix": "_PrefixPublicKeyIndexToTransactionIDs:16",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPostHashToPostEntry key/value pair
+func decodePostHashToPostEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var PE lib.PostEntry
+	err := dec.Decode(&PE)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := PostDoc{
+		DocMeta:                        newDocMeta("A User's Post or Subcomment.", "_PrefixPostHashToPostEntry:17"),
+		PostHash:                       optionalBlockHash(PE.PostHash),
+		PosterPublicKey:                lib.PkToStringBoth(PE.PosterPublicKey),
+		ParentStakeID:                  stakeIDToString(PE.ParentStakeID),
+		Body:                           string(PE.Body),
+		RepostedPostHash:               optionalBlockHash(PE.RepostedPostHash),
+		IsQuotedRepost:                 PE.IsQuotedRepost,
+		CreatorBasisPoints:             PE.CreatorBasisPoints,
+		StakeMultipleBasisPoints:       PE.StakeMultipleBasisPoints,
+		ConfirmationBlockHeight:        PE.ConfirmationBlockHeight,
+		TimestampNanos:                 PE.TimestampNanos,
+		IsHidden:                       PE.IsHidden,
+		LikeCount:                      PE.LikeCount,
+		RepostCount:                    PE.RepostCount,
+		QuoteRepostCount:               PE.QuoteRepostCount,
+		DiamondCount:                   PE.DiamondCount,
+		CommentCount:                   PE.CommentCount,
+		IsPinned:                       PE.IsPinned,
+		IsNFT:                          PE.IsNFT,
+		NumNFTCopies:                   PE.NumNFTCopies,
+		NumNFTCopiesForSale:            PE.NumNFTCopiesForSale,
+		HasUnlockable:                  PE.HasUnlockable,
+		NFTRoyaltyToCreatorBasisPoints: PE.NFTRoyaltyToCreatorBasisPoints,
+		NFTRoyaltyToCoinBasisPoints:    PE.NFTRoyaltyToCoinBasisPoints,
+		PostExtraData:                  PE.PostExtraData,
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixPosterPublicKeyPostHash key/value pair
+func decodePosterPublicKeyPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var newHash lib.BlockHash
+	copy(newHash[:], key[34:])
+
+	docMap := map[string]interface{}{
+		"PublicKey":       key[1:34],
+		"PostHash":        newHash,
+		"MongoMeta":       "An association between a PostHash and its corresponding public key.",
+		"BadgerKeyPrefix": "_PrefixPosterPublicKeyPostHash:18",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixTstampNanosPostHash key/value pair
+func decodeTstampNanosPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var newHash lib.BlockHash
+	copy(newHash[:], key[9:])
+
+	docMap := map[string]interface{}{
+		"TstampNanos":     lib.DecodeUint64(key[1:9]),
+		"PostHash":        newHash,
+		"MongoMeta":       "An association between a PostHash and its corresponding time stamp (in nanos).",
+		"BadgerKeyPrefix": "_PrefixTstampNanosPostHash:19",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixCreatorBpsPostHash key/value pair
+func decodeCreatorBpsPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var newHash lib.BlockHash
+	copy(newHash[:], key[9:])
+
+	docMap := map[string]interface{}{
+		"TstampNanos":     lib.DecodeUint64(key[1:9]),
+		"PostHash":        newHash,
+		"MongoMeta":       "An association between a PostHash and its corresponding creator basis points founder reward.",
+		"BadgerKeyPrefix": "_PrefixCreatorBpsPostHash:20",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixMultipleBpsPostHash key/value pair
+func decodeMultipleBpsPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var newHash lib.BlockHash
+	copy(newHash[:], key[9:])
+
+	docMap := map[string]interface{}{
+		"TstampNanos":     lib.DecodeUint64(key[1:9]),
+		"PostHash":        newHash,
+		"MongoMeta":       "An association between a PostHash and its multiplier basis points.",
+		"BadgerKeyPrefix": "_PrefixMultipleBpsPostHash:21",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixCommentParentStakeIDToPostHash key/value pair
+func decodeCommentParentStakeIDToPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var newHash lib.BlockHash
+	copy(newHash[:], key[42:])
+
+	docMap := map[string]interface{}{
+		"ParentStakeID":   key[1:34],
+		"TstampNanos":     lib.DecodeUint64(key[34:42]),
+		"PostHash":        newHash,
+		"MongoMeta":       "An association between a comment PostHash and it's corresponding parent post stakeID.",
+		"BadgerKeyPrefix": "_PrefixCommentParentStakeIDToPostHash:22",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPKIDToProfileEntry key/value pair
+func decodePKIDToProfileEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var PE lib.ProfileEntry
+	err := dec.Decode(&PE)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := ProfileDoc{
+		DocMeta:     newDocMeta("A User's Profile.", "_PrefixProfilePubKeyToProfileEntry:23"),
+		PublicKey:   lib.PkToStringBoth(PE.PublicKey),
+		Username:    string(PE.Username),
+		Description: string(PE.Description),
+		ProfilePic:  string(PE.ProfilePic),
+		IsHidden:    PE.IsHidden,
+		CoinEntry: CoinEntryDoc{
+			CreatorBasisPoints:      PE.CoinEntry.CreatorBasisPoints,
+			DeSoLockedNanos:         PE.CoinEntry.DeSoLockedNanos,
+			NumberOfHolders:         PE.CoinEntry.NumberOfHolders,
+			CoinsInCirculationNanos: PE.CoinEntry.CoinsInCirculationNanos,
+			CoinWatermarkNanos:      PE.CoinEntry.CoinWatermarkNanos,
+		},
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixProfileStakeToProfilePubKey key/value pair
+func decodeProfileStakeToProfilePubKey(key []byte, val []byte) (map[string]interface{}, error) {
+	docMap := map[string]interface{}{
+		"Stake":           lib.DecodeUint64(key[1:9]),
+		"PublicKey":       key[9:],
+		"MongoMeta":       "Depricated.",
+		"BadgerKeyPrefix": "_PrefixProfileStakeToProfilePubKey:24",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixProfileUsernameToPKID key/value pair
+func decodeProfileUsernameToPKID(key []byte, val []byte) (map[string]interface{}, error) {
+	pubKey := val
+	docMap := map[string]interface{}{
+		"Username":        key[1:],
+		"PKID":            pubKey,
+		"MongoMeta":       "A user's username and their corresponding PKID.",
+		"BadgerKeyPrefix": "_PrefixProfileUsernameToProfilePubKey:25",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixStakeIDTypeAmountStakeIDIndex key/value pair
+func decodeStakeIDTypeAmountStakeIDIndex(key []byte, val []byte) (map[string]interface{}, error) {
+	docMap := map[string]interface{}{
+		"StakeType":       "",
+		"AmountNanos":     key[2:10],
+		"StakeID":         key[10:],
+		"MongoMeta":       "A stake ID and its corresponding stakes nanos.",
+		"BadgerKeyPrefix": "_PrefixStakeIDTypeAmountStakeIDIndex:26",
+	}
+	if key[1] == 0 {
+		docMap["StakeType"] = "Post"
+	} else {
+		docMap["StakeType"] = "Profile"
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _KeyUSDCentsPerBitcoinExchangeRate key/value pair
+func decodeUSDCentsPerBitcoinExchangeRate(key []byte, val []byte) (map[string]interface{}, error) {
+	var exchange uint64
+	exchange = lib.DecodeUint64(val)
+
+	docMap := map[string]interface{}{
+		"USDCentsPerBitcoin": exchange,
+		"MongoMeta":          "The exchange rate in USD Cents for a bitcoin.",
+		"BadgerKeyPrefix":    "_KeyUSDCentsPerBitcoinExchangeRate:27",
+	}
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixFollowerPKIDToFollowedPKID key/value pair
+func decodeFollowerPKIDToFollowedPKID(key []byte, val []byte) (map[string]interface{}, error) {
+	doc := FollowDoc{
+		DocMeta:      newDocMeta("A user's PKID (follower) and the PKID of those they follow (followed).", "_PrefixFollowerPubKeyToFollowedPubKey:28"),
+		FollowerPKID: lib.PkToStringBoth(key[1:34]),
+		FollowedPKID: lib.PkToStringBoth(key[34:]),
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixFollowedPKIDToFollowerPKID key/value pair
+func decodeFollowedPKIDToFollowerPKID(key []byte, val []byte) (map[string]interface{}, error) {
+	doc := FollowDoc{
+		DocMeta:      newDocMeta("A user's PKID (followed) and the PKID of those who follow them (follower).", "_PrefixFollowedPubKeyToFollowerPubKey:29"),
+		FollowedPKID: lib.PkToStringBoth(key[1:34]),
+		FollowerPKID: lib.PkToStringBoth(key[34:]),
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixLikerPubKeyToLikedPostHash key/value pair
+func decodeLikerPubKeyToLikedPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var likedPost lib.BlockHash
+	copy(likedPost[:], key[34:])
+
+	doc := LikeDoc{
+		DocMeta:       newDocMeta("A user's public key and the post hash of one of their liked posts.", "_PrefixLikerPubKeyToLikedPostHash:30"),
+		PublicKey:     lib.PkToStringBoth(key[1:34]),
+		LikedPostHash: likedPost.String(),
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixLikedPostHashToLikerPubKey key/value pair
+func decodeLikedPostHashToLikerPubKey(key []byte, val []byte) (map[string]interface{}, error) {
+	// <prefix, liked post hash [32]byte, liker public key [33]byte>
+	var likedPost lib.BlockHash
+	copy(likedPost[:], key[1:33])
+
+	doc := LikeDoc{
+		DocMeta:       newDocMeta("A PostHash and a corresponding public key of someone who liked that post.", "_PrefixLikedPostHashToLikerPubKey:31"),
+		PublicKey:     lib.PkToStringBoth(key[33:]),
+		LikedPostHash: likedPost.String(),
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixCreatorDeSoLockedNanosCreatorPKID key/value pair
+func decodeCreatorDeSoLockedNanosCreatorPKID(key []byte, val []byte) (map[string]interface{}, error) {
+	docMap := map[string]interface{}{
+		"PKID":            key[9:],
+		"DESOLockedNanos": lib.DecodeUint64(key[1:9]),
+		"MongoMeta":       "The amount of DESO locked in a particular profile's PKID.",
+		"BadgerKeyPrefix": "_PrefixCreatorDESOLockedNanosCreatorPubKeyIIndex:32",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixHODLerPKIDCreatorPKIDToBalanceEntry key/value pair
+func decodeHODLerPKIDCreatorPKIDToBalanceEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var BE lib.BalanceEntry
+	err := dec.Decode(&BE)
+	if err != nil {
+		return nil, err
+	}
+
+	return docToMap(newBalanceDoc(&BE, "A user's (HODLerPubKey) balance of held (CreatorPubKey).", "_PrefixHODLerPubKeyCreatorPubKeyToBalanceEntry:33")), nil
+}
+
+// Decodes a _PrefixCreatorPKIDHODLerPKIDToBalanceEntry key/value pair
+func decodeCreatorPKIDHODLerPKIDToBalanceEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var BE lib.BalanceEntry
+	err := dec.Decode(&BE)
+	if err != nil {
+		return nil, err
+	}
+
+	return docToMap(newBalanceDoc(&BE, "A ceator's (CreatorPubKey) hodlers (HODLerPubKey) and their associated balances.", "_PrefixCreatorPubKeyHODLerPubKeyToBalanceEntry:34")), nil
+}
+
+// Decodes a _PrefixPosterPublicKeyTimestampPostHash key/value pair
+func decodePosterPublicKeyTimestampPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var ph lib.BlockHash
+	copy(ph[:], key[42:])
+
+	docMap := map[string]interface{}{
+		"PublicKey":       key[1:34],
+		"PostHash":        ph,
+		"TStampNanos":     lib.DecodeUint64(key[34:42]),
+		"MongoMeta":       "The PostHash of a post generated by a user's public key and the corresponding time in nanos.",
+		"BadgerKeyPrefix": "_PrefixPosterPublicKeyTimestampPostHash:35",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPublicKeyToPKID key/value pair
+func decodePublicKeyToPKID(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var PE lib.PKIDEntry
+	err := dec.Decode(&PE)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := structs.Map(PE)
+	pkp := docMap["PKID"].(*lib.PKID)
+	pk := make([]byte, len(*pkp))
+	for i, v := range *pkp {
+		pk[i] = v
+	}
+	docMap["PKID"] = pk
+
+	SimplifyMap(&docMap)
+	docMap["MongoMeta"] = "A mapping of a public key to it's corresponding PKID."
+	docMap["BadgerKeyPrefix"] = "_PrefixPublicKeyToPKID:36"
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPKIDToPublicKey key/value pair
+func decodePKIDToPublicKey(key []byte, val []byte) (map[string]interface{}, error) {
+	docMap := map[string]interface{}{
+		"PublicKey":       val,
+		"PKID":            key[1:34],
+		"MongoMeta":       "A map of a PKID to it's corresponding public key.",
+		"BadgerKeyPrefix": "_PrefixPKIDToPublicKey:37",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixReposterPubKeyRepostedPostHashToRepostPostHash key/value pair
+func decodeReposterPubKeyRepostedPostHashToRepostPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var RE lib.RepostEntry
+	err := dec.Decode(&RE)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := structs.Map(RE) // Convert to map
+	SimplifyMap(&docMap)
+	docMap["MongoMeta"] = "A user's public key and the post hash of one of the post they reposted"
+	docMap["BadgerKeyPrefix"] = "_PrefixReposterPubKeyRepostedPostHashToRepostPostHash:39"
+
+	return docMap, nil
+}
+
+// Decodes a _KeyGlobalParams key/value pair
+func decodeGlobalParams(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var GPE lib.GlobalParamsEntry
+	err := dec.Decode(&GPE)
+	if err != nil {
+		return nil, err
+	}
+	docMap := structs.Map(GPE)
+	docMap["MongoMeta"] = "Global Params Entry"
+	docMap["BadgerKeyPrefix"] = "_KeyGlobalPArams"
+	return docMap, nil
+}
+
+// Decodes a _PrefixMempoolTxnHashToMsgDeSoTxn key/value pair
+func decodeMempoolTxnHashToMsgDeSoTxn(key []byte, val []byte) (map[string]interface{}, error) {
+	var txnHash lib.BlockHash
+	copy(txnHash[:], key[1:])
+
+	txn := &lib.MsgDeSoTxn{}
+	err := txn.FromBytes(val)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := map[string]interface{}{
+		"TxnHash":         txnHash,
+		"PublicKey":       txn.PublicKey,
+		"TxnType":         "",
+		"MongoMeta":       "A transaction that was in the mempool when the node last shut down.",
+		"BadgerKeyPrefix": "_PrefixMempoolTxnHashToMsgDeSoTxn:38",
+	}
+	if txn.TxnMeta != nil {
+		docMap["TxnType"] = txn.TxnMeta.GetTxnType().String()
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixDiamondReceiverPKIDDiamondSenderPKIDPostHash key/value pair
+func decodeDiamondReceiverPKIDDiamondSenderPKIDPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var DE lib.DiamondEntry
+	err := dec.Decode(&DE)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := DiamondDoc{
+		DocMeta:         newDocMeta("Diamonds a user (ReceiverPKID) received from another user (SenderPKID) on a post.", "_PrefixDiamondReceiverPKIDDiamondSenderPKIDPostHash:41"),
+		SenderPKID:      optionalPKID(DE.SenderPKID),
+		ReceiverPKID:    optionalPKID(DE.ReceiverPKID),
+		DiamondPostHash: optionalBlockHash(DE.DiamondPostHash),
+		DiamondLevel:    DE.DiamondLevel,
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixPublicKeyToNextIndex key/value pair
+func decodePublicKeyToNextIndex(key []byte, val []byte) (map[string]interface{}, error) {
+	if len(val) != 4 {
+		return nil, fmt.Errorf("Invalid value length %d, expected 4", len(val))
+	}
+
+	docMap := map[string]interface{}{
+		"PublicKey":       key[1:],
+		"NextIndex":       binary.BigEndian.Uint32(val),
+		"MongoMeta":       "The index the next transaction involving a public key is stored under in _PrefixPublicKeyIndexToTransactionIDs.",
+		"BadgerKeyPrefix": "_PrefixPublicKeyToNextIndex:42",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixDiamondSenderPKIDDiamondReceiverPKIDPostHash key/value pair
+func decodeDiamondSenderPKIDDiamondReceiverPKIDPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var DE lib.DiamondEntry
+	err := dec.Decode(&DE)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := DiamondDoc{
+		DocMeta:         newDocMeta("Diamonds a user (SenderPKID) gave another user (ReceiverPKID) on a post.", "_PrefixDiamondSenderPKIDDiamondReceiverPKIDPostHash:43"),
+		SenderPKID:      optionalPKID(DE.SenderPKID),
+		ReceiverPKID:    optionalPKID(DE.ReceiverPKID),
+		DiamondPostHash: optionalBlockHash(DE.DiamondPostHash),
+		DiamondLevel:    DE.DiamondLevel,
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixForbiddenBlockSignaturePubKeys key/value pair
+func decodeForbiddenBlockSignaturePubKeys(key []byte, val []byte) (map[string]interface{}, error) {
+	docMap := map[string]interface{}{
+		"PublicKey":       key[1:],
+		"MongoMeta":       "A public key whose block signatures are rejected.",
+		"BadgerKeyPrefix": "_PrefixForbiddenBlockSignaturePubKeys:44",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixRepostedPostHashReposterPubKey key/value pair
+func decodeRepostedPostHashReposterPubKey(key []byte, val []byte) (map[string]interface{}, error) {
+	var repostedPostHash lib.BlockHash
+	copy(repostedPostHash[:], key[1:33])
+
+	docMap := map[string]interface{}{
+		"RepostedPostHash": repostedPostHash,
+		"PublicKey":        key[33:],
+		"MongoMeta":        "A reposted post's PostHash and the public key of a user who reposted it.",
+		"BadgerKeyPrefix":  "_PrefixRepostedPostHashReposterPubKey:45",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixRepostedPostHashReposterPubKeyRepostPostHash key/value pair
+func decodeRepostedPostHashReposterPubKeyRepostPostHash(key []byte, val []byte) (map[string]interface{}, error) {
+	var repostedPostHash, repostPostHash lib.BlockHash
+	copy(repostedPostHash[:], key[1:33])
+	copy(repostPostHash[:], key[66:])
+
+	docMap := map[string]interface{}{
+		"RepostedPostHash": repostedPostHash,
+		"PublicKey":        key[33:66],
+		"RepostPostHash":   repostPostHash,
+		"MongoMeta":        "A reposted post's PostHash, the public key of a user who quote reposted it and the quote repost's PostHash.",
+		"BadgerKeyPrefix":  "_PrefixRepostedPostHashReposterPubKeyRepostPostHash:46",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPostHashSerialNumberToNFTEntry key/value pair
+func decodePostHashSerialNumberToNFTEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var NE lib.NFTEntry
+	err := dec.Decode(&NE)
+	if err != nil {
+		return nil, err
+	}
+
+	return docToMap(newNFTDoc(&NE, "A serial number of an NFT and its owner.", "_PrefixPostHashSerialNumberToNFTEntry:47")), nil
+}
+
+// Decodes a _PrefixPKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry key/value pair
+func decodePKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var NE lib.NFTEntry
+	err := dec.Decode(&NE)
+	if err != nil {
+		return nil, err
+	}
+
+	return docToMap(newNFTDoc(&NE, "A serial number of an NFT indexed by its owner, whether it's for sale and its minimum bid.", "_PrefixPKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry:48")), nil
+}
+
+// Decodes a _PrefixPostHashSerialNumberBidNanosBidderPKID key/value pair
+func decodePostHashSerialNumberBidNanosBidderPKID(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var NBE lib.NFTBidEntry
+	err := dec.Decode(&NBE)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := NFTBidDoc{
+		DocMeta:             newDocMeta("A bid on a serial number of an NFT.", "_PrefixPostHashSerialNumberBidNanosBidderPKID:49"),
+		BidderPKID:          optionalPKID(NBE.BidderPKID),
+		NFTPostHash:         optionalBlockHash(NBE.NFTPostHash),
+		SerialNumber:        NBE.SerialNumber,
+		BidAmountNanos:      NBE.BidAmountNanos,
+		AcceptedBlockHeight: NBE.AcceptedBlockHeight,
+	}
+
+	return docToMap(doc), nil
+}
+
+// Decodes a _PrefixBidderPKIDPostHashSerialNumberToBidNanos key/value pair
+func decodeBidderPKIDPostHashSerialNumberToBidNanos(key []byte, val []byte) (map[string]interface{}, error) {
+	var postHash lib.BlockHash
+	copy(postHash[:], key[34:66])
+
+	docMap := map[string]interface{}{
+		"BidderPKID":      lib.PkToStringBoth(key[1:34]),
+		"NFTPostHash":     postHash,
+		"SerialNumber":    lib.DecodeUint64(key[66:74]),
+		"BidAmountNanos":  lib.DecodeUint64(val),
+		"MongoMeta":       "A user's (BidderPKID) bid on a serial number of an NFT.",
+		"BadgerKeyPrefix": "_PrefixBidderPKIDPostHashSerialNumberToBidNanos:50",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPublicKeyToDeSoBalanceNanos key/value pair
+func decodePublicKeyToDeSoBalanceNanos(key []byte, val []byte) (map[string]interface{}, error) {
+	docMap := map[string]interface{}{
+		"PublicKey":       key[1:],
+		"BalanceNanos":    lib.DecodeUint64(val),
+		"MongoMeta":       "A user's DESO balance in nanos.",
+		"BadgerKeyPrefix": "_PrefixPublicKeyToDeSoBalanceNanos:51",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPublicKeyBlockHashToBlockReward key/value pair
+func decodePublicKeyBlockHashToBlockReward(key []byte, val []byte) (map[string]interface{}, error) {
+	var blockHash lib.BlockHash
+	copy(blockHash[:], key[34:])
+
+	docMap := map[string]interface{}{
+		"PublicKey":        key[1:34],
+		"BlockHash":        blockHash,
+		"BlockRewardNanos": lib.DecodeUint64(val),
+		"MongoMeta":        "The block reward in nanos a public key received for a block.",
+		"BadgerKeyPrefix":  "_PrefixPublicKeyBlockHashToBlockReward:52",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixPostHashSerialNumberToAcceptedBidEntries key/value pair
+func decodePostHashSerialNumberToAcceptedBidEntries(key []byte, val []byte) (map[string]interface{}, error) {
+	var postHash lib.BlockHash
+	copy(postHash[:], key[1:33])
+
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var bidEntries []*lib.NFTBidEntry
+	err := dec.Decode(&bidEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	acceptedBids := make([]interface{}, 0, len(bidEntries))
+	for _, bidEntry := range bidEntries {
+		if bidEntry == nil {
+			continue
+		}
+		bidMap := structs.Map(*bidEntry)
+		SimplifyMap(&bidMap)
+		acceptedBids = append(acceptedBids, bidMap)
+	}
+
+	docMap := map[string]interface{}{
+		"NFTPostHash":     postHash,
+		"SerialNumber":    lib.DecodeUint64(key[33:41]),
+		"AcceptedBids":    acceptedBids,
+		"MongoMeta":       "The bids accepted for a serial number of an NFT, oldest first.",
+		"BadgerKeyPrefix": "_PrefixPostHashSerialNumberToAcceptedBidEntries:53",
+	}
+	SimplifyMap(&docMap)
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixAuthorizeDerivedKey key/value pair
+func decodeAuthorizeDerivedKey(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var DKE lib.DerivedKeyEntry
+	err := dec.Decode(&DKE)
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := map[string]interface{}{
+		"OwnerPublicKey":   lib.PkToStringBoth(DKE.OwnerPublicKey[:]),
+		"DerivedPublicKey": lib.PkToStringBoth(DKE.DerivedPublicKey[:]),
+		"ExpirationBlock":  DKE.ExpirationBlock,
+		"OperationType":    DKE.OperationType,
+		"MongoMeta":        "A derived key a user (OwnerPublicKey) authorized to sign transactions on their behalf.",
+		"BadgerKeyPrefix":  "_PrefixAuthorizeDerivedKey:54",
+	}
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixHODLerPKIDCreatorPKIDToDAOCoinBalanceEntry key/value pair
+func decodeHODLerPKIDCreatorPKIDToDAOCoinBalanceEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var BE lib.BalanceEntry
+	err := dec.Decode(&BE)
+	if err != nil {
+		return nil, err
+	}
+
+	return docToMap(newBalanceDoc(&BE, "A user's (HODLerPKID) balance of a creator's (CreatorPKID) DAO coin.", "_PrefixHODLerPKIDCreatorPKIDToDAOCoinBalanceEntry:55")), nil
+}
+
+// Decodes a _PrefixCreatorPKIDHODLerPKIDToDAOCoinBalanceEntry key/value pair
+func decodeCreatorPKIDHODLerPKIDToDAOCoinBalanceEntry(key []byte, val []byte) (map[string]interface{}, error) {
+	dec := gob.NewDecoder(bytes.NewReader(val))
+	var BE lib.BalanceEntry
+	err := dec.Decode(&BE)
+	if err != nil {
+		return nil, err
+	}
+
+	return docToMap(newBalanceDoc(&BE, "A creator's (CreatorPKID) DAO coin holders (HODLerPKID) and their balances.", "_PrefixCreatorPKIDHODLerPKIDToDAOCoinBalanceEntry:56")), nil
+}
+
+// Decodes a _PrefixMessagingGroupEntriesByOwnerPubKeyAndGroupKeyName key/value pair
+func decodeMessagingGroupEntriesByOwnerPubKeyAndGroupKeyName(key []byte, val []byte) (map[string]interface{}, error) {
+	// Core writes messaging group entries with their own encoding rather than gob
+	var MGE lib.MessagingGroupEntry
+	err := MGE.Decode(val)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]interface{}, 0, len(MGE.MessagingGroupMembers))
+	for _, member := range MGE.MessagingGroupMembers {
+		if member == nil {
+			continue
+		}
+		members = append(members, messagingGroupMemberToMap(member))
+	}
+
+	docMap := map[string]interface{}{
+		"GroupOwnerPublicKey":   publicKeyToString(MGE.GroupOwnerPublicKey),
+		"MessagingPublicKey":    publicKeyToString(MGE.MessagingPublicKey),
+		"MessagingGroupKeyName": groupKeyNameToString(MGE.MessagingGroupKeyName),
+		"MessagingGroupMembers": members,
+		"MongoMeta":             "A messaging key or group chat registered by a user (GroupOwnerPublicKey).",
+		"BadgerKeyPrefix":       "_PrefixMessagingGroupEntriesByOwnerPubKeyAndGroupKeyName:57",
+	}
+
+	return docMap, nil
+}
+
+// Decodes a _PrefixMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey key/value pair
+func decodeMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey(key []byte, val []byte) (map[string]interface{}, error) {
+	// Core writes messaging group members with their own encoding rather than gob
+	var MGM lib.MessagingGroupMember
+	err := MGM.Decode(bytes.NewReader(val))
+	if err != nil {
+		return nil, err
+	}
+
+	docMap := messagingGroupMemberToMap(&MGM)
+	docMap["GroupMessagingPublicKey"] = lib.PkToStringBoth(key[34:])
+	docMap["MongoMeta"] = "A user's (GroupMemberPublicKey) membership in a group chat (GroupMessagingPublicKey)."
+	docMap["BadgerKeyPrefix"] = "_PrefixMessagingGroupMetadataByMemberPubKeyAndGroupMessagingPubKey:58"
+
+	return docMap, nil
+}
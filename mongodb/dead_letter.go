@@ -0,0 +1,406 @@
+package mongodb
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// This file contains the dead-letter store recording badgerDB records that failed to sync
+
+// DeadLetterStoreType names a DeadLetterStore implementation selectable from the command line
+type DeadLetterStoreType string
+
+const (
+	// DeadLetterStoreNone discards failed records after logging them
+	DeadLetterStoreNone DeadLetterStoreType = "none"
+	// DeadLetterStoreMongo records failed records in a mongoDB collection
+	DeadLetterStoreMongo DeadLetterStoreType = "mongo"
+	// DeadLetterStoreFile records failed records as newline delimited JSON in a local file
+	DeadLetterStoreFile DeadLetterStoreType = "file"
+)
+
+// DeadLetterStage names the step a dead-lettered record failed at
+type DeadLetterStage string
+
+const (
+	// DeadLetterStageDecode means the badgerDB value couldn't be decoded
+	DeadLetterStageDecode DeadLetterStage = "decode"
+	// DeadLetterStageUpsert means the decoded record couldn't be written to the sink
+	DeadLetterStageUpsert DeadLetterStage = "upsert"
+	// DeadLetterStageDelete means the record of a deleted key couldn't be removed from the sink
+	DeadLetterStageDelete DeadLetterStage = "delete"
+)
+
+// DeadLetter describes a badgerDB record that failed to sync
+type DeadLetter struct {
+	// Key holds the hex encoded badgerDB key. A store holds at most one letter per key.
+	Key string `bson:"_id" json:"Key"`
+	// Value holds the hex encoded badgerDB value. Empty for failed deletes.
+	Value string `bson:"Value" json:"Value"`
+	// Prefix and PrefixName identify the key's prefix
+	Prefix     int    `bson:"Prefix" json:"Prefix"`
+	PrefixName string `bson:"PrefixName" json:"PrefixName"`
+	// Stage holds the step the record failed at
+	Stage DeadLetterStage `bson:"Stage" json:"Stage"`
+	// Error holds the error message of the failure
+	Error string `bson:"Error" json:"Error"`
+	// FailedAt holds the wall-clock time of the failure
+	FailedAt time.Time `bson:"FailedAt" json:"FailedAt"`
+}
+
+// DeadLetterStore records badgerDB records that failed to sync so that
+// they can be retried later. Implementations must be safe for concurrent use,
+// since the writer goroutines of a batchWriter add letters concurrently.
+type DeadLetterStore interface {
+	// AddDeadLetters records letters, replacing any letter with the same key
+	AddDeadLetters(letters []*DeadLetter) error
+	// ForEachDeadLetter calls fn with every recorded letter
+	ForEachDeadLetter(fn func(letter *DeadLetter) error) error
+	// RemoveDeadLetters removes the letters recorded for the hex encoded keys
+	RemoveDeadLetters(keys []string) error
+}
+
+// Initializes and returns a DeadLetter for the badgerDB key/value pair that failed at stage
+func newDeadLetter(key []byte, val []byte, stage DeadLetterStage, err error) *DeadLetter {
+	letter := &DeadLetter{
+		Key:      hex.EncodeToString(key),
+		Value:    hex.EncodeToString(val),
+		Stage:    stage,
+		Error:    err.Error(),
+		FailedAt: time.Now(),
+	}
+	if len(key) != 0 {
+		letter.Prefix = int(key[0])
+		letter.PrefixName = PrefixName(key[0])
+	}
+	return letter
+}
+
+// Returns a DeadLetter for every record whose upsert failed with err
+func upsertDeadLetters(records []*Record, err error) []*DeadLetter {
+	var letters []*DeadLetter
+	for _, record := range records {
+		if recordErr := batchErrorForKey(err, record.Key); recordErr != nil {
+			letters = append(letters, newDeadLetter(record.Key, record.Value, DeadLetterStageUpsert, recordErr))
+		}
+	}
+	return letters
+}
+
+// Returns a DeadLetter for every key whose delete failed with err
+func deleteDeadLetters(keys [][]byte, err error) []*DeadLetter {
+	var letters []*DeadLetter
+	for _, key := range keys {
+		if keyErr := batchErrorForKey(err, key); keyErr != nil {
+			letters = append(letters, newDeadLetter(key, nil, DeadLetterStageDelete, keyErr))
+		}
+	}
+	return letters
+}
+
+// Returns the error key failed with when a batch write returned err. Errors
+// other than a BatchWriteError are taken to have failed the whole batch.
+func batchErrorForKey(err error, key []byte) error {
+	batchErr, ok := err.(*BatchWriteError)
+	if !ok {
+		return err
+	}
+	return batchErr.FailedKeys[string(key)]
+}
+
+// FileDeadLetterStore records dead letters as newline delimited JSON in a
+// local file. Letters are appended, so the file may hold several letters for
+// the same key, of which the last one is current.
+type FileDeadLetterStore struct {
+	// path holds the file the letters are written to
+	path string
+	// lock serializes the writes of concurrent batches
+	lock sync.Mutex
+	// keys holds the keys of the letters in the file once it was read, so that
+	// removing keys without letters doesn't rewrite the file
+	keys map[string]bool
+}
+
+// Initializes and returns a new FileDeadLetterStore writing to path
+func NewFileDeadLetterStore(path string) *FileDeadLetterStore {
+	return &FileDeadLetterStore{
+		path: path,
+	}
+}
+
+// Appends letters to the file
+func (store *FileDeadLetterStore) AddDeadLetters(letters []*DeadLetter) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	file, err := os.OpenFile(store.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, letter := range letters {
+		letterJSON, err := json.Marshal(letter)
+		if err != nil {
+			return err
+		}
+		writer.Write(letterJSON)
+		writer.WriteByte('\n')
+		if store.keys != nil {
+			store.keys[letter.Key] = true
+		}
+	}
+	return writer.Flush()
+}
+
+// Reads the current letter of every key from the file, in the order the keys were first recorded
+func (store *FileDeadLetterStore) readDeadLetters() ([]*DeadLetter, error) {
+	file, err := os.Open(store.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var letters []*DeadLetter
+	letterIndexes := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 64*1024*1024) // Values of large blocks don't fit the default buffer
+	for scanner.Scan() {
+		letter := &DeadLetter{}
+		if err := json.Unmarshal(scanner.Bytes(), letter); err != nil {
+			return nil, fmt.Errorf("Invalid dead letter in %v: %v", store.path, err)
+		}
+		if index, exists := letterIndexes[letter.Key]; exists {
+			letters[index] = letter
+			continue
+		}
+		letterIndexes[letter.Key] = len(letters)
+		letters = append(letters, letter)
+	}
+	return letters, scanner.Err()
+}
+
+// Calls fn with the current letter of every key in the file
+func (store *FileDeadLetterStore) ForEachDeadLetter(fn func(letter *DeadLetter) error) error {
+	letters, err := store.readDeadLetters()
+	if err != nil {
+		return err
+	}
+	for _, letter := range letters {
+		if err := fn(letter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rewrites the file without the letters of keys
+func (store *FileDeadLetterStore) RemoveDeadLetters(keys []string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if store.keys == nil {
+		letters, err := store.readDeadLetters()
+		if err != nil {
+			return err
+		}
+		store.keys = make(map[string]bool, len(letters))
+		for _, letter := range letters {
+			store.keys[letter.Key] = true
+		}
+	}
+	removed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if store.keys[key] {
+			removed[key] = true
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	letters, err := store.readDeadLetters()
+	if err != nil {
+		return err
+	}
+	var remaining []*DeadLetter
+	for _, letter := range letters {
+		if !removed[letter.Key] {
+			remaining = append(remaining, letter)
+		}
+	}
+
+	// Write the remaining letters to a new file and swap it in so that
+	// an interrupted rewrite doesn't lose any letters
+	tmpStore := NewFileDeadLetterStore(store.path + ".tmp")
+	if err := os.Remove(tmpStore.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := tmpStore.AddDeadLetters(remaining); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpStore.path, store.path); err != nil {
+		return err
+	}
+	for key := range removed {
+		delete(store.keys, key)
+	}
+	return nil
+}
+
+// DeadLetterRetrySummary describes a completed retry of the dead letters
+type DeadLetterRetrySummary struct {
+	// Retried counts the letters reprocessed
+	Retried int
+	// Succeeded counts the letters synced and removed from the store
+	Succeeded int
+	// Failed counts the letters that failed again and were kept
+	Failed int
+}
+
+// Reprocesses every dead letter, e.g. after a decoder fix. The key of each letter
+// is read from badgerDB again, since it may have been written or deleted after
+// the letter was recorded, and keys that no longer exist are deleted from the
+// sink. Letters that sync are removed from the store and letters that fail
+// again are kept with their new error.
+func (syncSrv *SyncingService) RetryDeadLetters() (*DeadLetterRetrySummary, error) {
+	if syncSrv.deadLetters == nil {
+		return nil, fmt.Errorf("No dead-letter store configured")
+	}
+	if syncSrv.DB == nil {
+		return nil, fmt.Errorf("No BadgerDB to read the current values of the dead letters from")
+	}
+
+	// Read all letters up front since retrying modifies the store
+	var letters []*DeadLetter
+	err := syncSrv.deadLetters.ForEachDeadLetter(func(letter *DeadLetter) error {
+		letters = append(letters, letter)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &DeadLetterRetrySummary{Retried: len(letters)}
+	for start := 0; start < len(letters); start += bulkWriteChunkSize {
+		end := start + bulkWriteChunkSize
+		if end > len(letters) {
+			end = len(letters)
+		}
+		failed, err := syncSrv.retryDeadLetterChunk(letters[start:end])
+		if err != nil {
+			return summary, err
+		}
+		summary.Failed += failed
+		summary.Succeeded += end - start - failed
+	}
+	return summary, nil
+}
+
+// Reprocesses letters and updates the store. Returns how many letters failed again.
+func (syncSrv *SyncingService) retryDeadLetterChunk(letters []*DeadLetter) (int, error) {
+	var records []*Record
+	var deleteKeys [][]byte
+	var failed []*DeadLetter
+	err := syncSrv.DB.View(func(txn *badger.Txn) error {
+		tipBlockHash, tipHeight := getChainTip(txn)
+		setSinkChainTip(syncSrv.Sink, tipHeight)
+
+		for _, letter := range letters {
+			key, err := hex.DecodeString(letter.Key)
+			if err != nil || len(key) == 0 {
+				// The letter is corrupt and is kept as it is for inspection
+				failed = append(failed, letter)
+				continue
+			}
+
+			item, err := txn.Get(key)
+			if err == badger.ErrKeyNotFound {
+				// The key was deleted from badgerDB
+				deleteKeys = append(deleteKeys, key)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			record, err := syncSrv.newRecord(key, val, &recordStamp{
+				BadgerVersion: item.Version(),
+				TipBlockHash:  tipBlockHash,
+				TipHeight:     tipHeight,
+			})
+			if err != nil {
+				failed = append(failed, newDeadLetter(key, val, DeadLetterStageDecode, err))
+				continue
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(records) != 0 {
+		if err := syncSrv.Sink.UpsertBatch(records); err != nil {
+			failed = append(failed, upsertDeadLetters(records, err)...)
+		}
+	}
+	if len(deleteKeys) != 0 {
+		if err := syncSrv.Sink.DeleteBatch(deleteKeys); err != nil {
+			failed = append(failed, deleteDeadLetters(deleteKeys, err)...)
+		}
+	}
+
+	// The cached hashes don't describe the rewritten documents, so the
+	// records are written again by the next pass that sees them
+	if syncSrv.hashCache != nil {
+		uncached := deleteKeys
+		for _, record := range records {
+			uncached = append(uncached, record.Key)
+		}
+		if err := syncSrv.hashCache.update(nil, uncached); err != nil {
+			fmt.Printf("Failed to update hash cache: %v\n", err)
+		}
+	}
+
+	// Update the failed letters before removing the others so that
+	// no letter is lost if the store fails in between
+	if len(failed) != 0 {
+		if err := syncSrv.deadLetters.AddDeadLetters(failed); err != nil {
+			return 0, err
+		}
+	}
+	failedKeys := make(map[string]bool, len(failed))
+	for _, letter := range failed {
+		failedKeys[letter.Key] = true
+	}
+	var succeededKeys []string
+	for _, letter := range letters {
+		if !failedKeys[letter.Key] {
+			succeededKeys = append(succeededKeys, letter.Key)
+		}
+	}
+	if len(succeededKeys) != 0 {
+		if err := syncSrv.deadLetters.RemoveDeadLetters(succeededKeys); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(failed), nil
+}
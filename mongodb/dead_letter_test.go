@@ -0,0 +1,124 @@
+package mongodb
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// recordingSink is a Sink recording the keys it upserts and deletes
+type recordingSink struct {
+	discardSink
+	upserted []*Record
+	deleted  [][]byte
+}
+
+func (sink *recordingSink) UpsertBatch(records []*Record) error {
+	sink.upserted = append(sink.upserted, records...)
+	return nil
+}
+
+func (sink *recordingSink) DeleteBatch(keys [][]byte) error {
+	sink.deleted = append(sink.deleted, keys...)
+	return nil
+}
+
+// Returns a follow key, which decodes without a value
+func testFollowKey(id byte) []byte {
+	// <prefix, follower PKID [33]byte, followed PKID [33]byte>
+	key := make([]byte, 67)
+	key[0] = 28
+	key[1] = id
+	key[34] = id
+	return key
+}
+
+func TestRetryDeadLettersReadsCurrentValues(t *testing.T) {
+	db, err := badger.Open(badger.DefaultOptions(t.TempDir()).WithLogger(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	hashCache, err := OpenHashCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hashCache.Close()
+
+	// The first key still exists with a newer value than its letter holds,
+	// the second key was deleted after its letter was recorded
+	existingKey, deletedKey := testFollowKey(1), testFollowKey(2)
+	err = db.Update(func(txn *badger.Txn) error {
+		return txn.Set(existingKey, []byte{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cachedHash := []byte("cached")
+	if err := hashCache.update(map[string][]byte{string(existingKey): cachedHash}, nil); err != nil {
+		t.Fatal(err)
+	}
+	deadLetters := &memoryDeadLetterStore{letters: []*DeadLetter{
+		{Key: hex.EncodeToString(existingKey), Value: "ff", Stage: DeadLetterStageUpsert},
+		{Key: hex.EncodeToString(deletedKey), Value: "ff", Stage: DeadLetterStageUpsert},
+	}}
+
+	sink := &recordingSink{}
+	syncSrv := NewSyncingService(db, sink, SyncModeFull, false, RecordEncodingBSON,
+		deadLetters, hashCache, 1, bulkWriteChunkSize, 1, DefaultRetryPolicy)
+	summary, err := syncSrv.RetryDeadLetters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Retried != 2 || summary.Succeeded != 2 {
+		t.Errorf("Got summary %+v, want 2 retried and succeeded", summary)
+	}
+	if len(sink.upserted) != 1 || string(sink.upserted[0].Key) != string(existingKey) ||
+		len(sink.upserted[0].Value) != 0 {
+		t.Errorf("Upserted %d records, want the current value of the existing key", len(sink.upserted))
+	}
+	if len(sink.deleted) != 1 || string(sink.deleted[0]) != string(deletedKey) {
+		t.Errorf("Deleted %d keys, want the deleted key", len(sink.deleted))
+	}
+	if len(deadLetters.letters) != 0 {
+		t.Errorf("Kept %d dead letters, want none", len(deadLetters.letters))
+	}
+	if hashCache.unchanged(existingKey, cachedHash) {
+		t.Error("The hash of the rewritten record is still cached")
+	}
+}
+
+func TestFileDeadLetterStore(t *testing.T) {
+	store := NewFileDeadLetterStore(filepath.Join(t.TempDir(), "dead_letters.jsonl"))
+	// Removing keys before the file exists succeeds
+	if err := store.RemoveDeadLetters([]string{"00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := store.AddDeadLetters([]*DeadLetter{
+		{Key: "01", Error: "first"},
+		{Key: "02", Error: "first"},
+		{Key: "01", Error: "second"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RemoveDeadLetters([]string{"02", "03"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var letters []*DeadLetter
+	err = store.ForEachDeadLetter(func(letter *DeadLetter) error {
+		letters = append(letters, letter)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(letters) != 1 || letters[0].Key != "01" || letters[0].Error != "second" {
+		t.Errorf("Got %d letters, want the second letter of key 01", len(letters))
+	}
+}
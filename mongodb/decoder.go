@@ -0,0 +1,115 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// This file contains the registry of decoders turning badgerDB key/value pairs into documents
+
+// Decoder decodes the badgerDB key/value pairs stored under a single key prefix.
+// Packages decoding prefixes the dumper doesn't know, e.g. those of a core
+// fork, implement it and register their decoders with RegisterDecoder.
+type Decoder interface {
+	// Prefix returns the badgerDB key prefix the decoder handles
+	Prefix() byte
+	// Name returns the name of the prefix, e.g. "_PrefixPostHashToPostEntry"
+	Name() string
+	// Decode decodes a key/value pair into a document
+	Decode(key []byte, val []byte) (map[string]interface{}, error)
+}
+
+// ErrUnknownPrefix is returned by DecodeKeyVal for keys whose prefix has no registered decoder
+var ErrUnknownPrefix = errors.New("Unknown key prefix")
+
+// funcDecoder is a Decoder calling a decode function
+type funcDecoder struct {
+	prefix byte
+	name   string
+	decode func(key []byte, val []byte) (map[string]interface{}, error)
+}
+
+// Initializes and returns a Decoder for prefix calling decode
+func NewDecoder(prefix byte, name string,
+	decode func(key []byte, val []byte) (map[string]interface{}, error)) Decoder {
+	return &funcDecoder{
+		prefix: prefix,
+		name:   name,
+		decode: decode,
+	}
+}
+
+func (decoder *funcDecoder) Prefix() byte {
+	return decoder.prefix
+}
+
+func (decoder *funcDecoder) Name() string {
+	return decoder.name
+}
+
+func (decoder *funcDecoder) Decode(key []byte, val []byte) (map[string]interface{}, error) {
+	return decoder.decode(key, val)
+}
+
+// Holds the registered decoders by prefix
+var decoders = make(map[byte]Decoder)
+var decodersLock sync.RWMutex
+
+// Registers decoder for its prefix, replacing any decoder registered for the
+// same prefix. Call it from an init function so that the decoder is in place
+// before syncing starts.
+func RegisterDecoder(decoder Decoder) {
+	decodersLock.Lock()
+	defer decodersLock.Unlock()
+
+	decoders[decoder.Prefix()] = decoder
+}
+
+// Returns the decoder registered for prefix, or nil if there is none
+func GetDecoder(prefix byte) Decoder {
+	decodersLock.RLock()
+	defer decodersLock.RUnlock()
+
+	return decoders[prefix]
+}
+
+// Returns the prefixes with a registered decoder in ascending order
+func RegisteredPrefixes() []byte {
+	decodersLock.RLock()
+	defer decodersLock.RUnlock()
+
+	prefixes := make([]byte, 0, len(decoders))
+	for prefix := range decoders {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i] < prefixes[j] })
+	return prefixes
+}
+
+// Decodes a badgerDB key/value pair with the decoder registered for the
+// key's prefix. Returns ErrUnknownPrefix if there is none. A decoder that
+// panics on a malformed value only fails the key it was decoding.
+func DecodeKeyVal(key []byte, val []byte) (docMap map[string]interface{}, err error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("Empty key")
+	}
+	decoder := GetDecoder(key[0])
+	if decoder == nil {
+		reportUnknownPrefix(key[0])
+		return nil, ErrUnknownPrefix
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			docMap = nil
+			err = fmt.Errorf("Decoding %v value panicked: %v", decoder.Name(), r)
+		}
+	}()
+	docMap, err = decoder.Decode(key, val)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode %v value: %v", decoder.Name(), err)
+	}
+	return docMap, nil
+}
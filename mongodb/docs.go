@@ -0,0 +1,462 @@
+package mongodb
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/deso-protocol/core/lib"
+)
+
+// This file contains the typed documents written for the main record kinds.
+// Their bson tags define the document schema, which is published as JSON Schema
+// by GenerateJSONSchema. Hashes and public keys are stored as strings, hashes as
+// hex and public keys and PKIDs in Base58Check.
+
+// DocMeta holds the fields every typed document carries
+type DocMeta struct {
+	// MongoMeta holds a human readable description of the document
+	MongoMeta string `bson:"MongoMeta" json:"MongoMeta"`
+	// BadgerKeyPrefix holds the name and number of the badgerDB key prefix
+	BadgerKeyPrefix string `bson:"BadgerKeyPrefix" json:"BadgerKeyPrefix"`
+	// The fields below are stamped on every document by the SyncingService
+	// and the sink, see recordStamp
+	BadgerVersion *uint64    `bson:"BadgerVersion,omitempty" json:"BadgerVersion,omitempty"`
+	TipHeight     *uint64    `bson:"TipHeight,omitempty" json:"TipHeight,omitempty"`
+	TipBlockHash  *string    `bson:"TipBlockHash,omitempty" json:"TipBlockHash,omitempty"`
+	FirstSeen     *time.Time `bson:"FirstSeen,omitempty" json:"FirstSeen,omitempty"`
+	LastChanged   *time.Time `bson:"LastChanged,omitempty" json:"LastChanged,omitempty"`
+	SchemaVersion *int       `bson:"SchemaVersion,omitempty" json:"SchemaVersion,omitempty"`
+	ContentHash   *string    `bson:"ContentHash,omitempty" json:"ContentHash,omitempty"`
+	// ValidFromHeight is stamped by the MongoSink in history mode
+	ValidFromHeight *uint64 `bson:"ValidFromHeight,omitempty" json:"ValidFromHeight,omitempty"`
+}
+
+// BlockHeaderDoc is the header of a block
+type BlockHeaderDoc struct {
+	Version               uint32  `bson:"Version" json:"Version"`
+	PrevBlockHash         *string `bson:"PrevBlockHash" json:"PrevBlockHash"`
+	TransactionMerkleRoot *string `bson:"TransactionMerkleRoot" json:"TransactionMerkleRoot"`
+	TstampSecs            uint64  `bson:"TstampSecs" json:"TstampSecs"`
+	Height                uint64  `bson:"Height" json:"Height"`
+	Nonce                 uint64  `bson:"Nonce" json:"Nonce"`
+	ExtraNonce            uint64  `bson:"ExtraNonce" json:"ExtraNonce"`
+}
+
+// TxnInputDoc is a UTXO spent by a transaction
+type TxnInputDoc struct {
+	TxID  string `bson:"TxID" json:"TxID"`
+	Index uint32 `bson:"Index" json:"Index"`
+}
+
+// TxnOutputDoc is a UTXO created by a transaction
+type TxnOutputDoc struct {
+	PublicKey   string `bson:"PublicKey" json:"PublicKey"`
+	AmountNanos uint64 `bson:"AmountNanos" json:"AmountNanos"`
+}
+
+// TxnDoc is a transaction within a block
+type TxnDoc struct {
+	TxnHash   *string        `bson:"TxnHash" json:"TxnHash"`
+	TxnType   string         `bson:"TxnType" json:"TxnType"`
+	PublicKey string         `bson:"PublicKey" json:"PublicKey"`
+	TxInputs  []TxnInputDoc  `bson:"TxInputs" json:"TxInputs"`
+	TxOutputs []TxnOutputDoc `bson:"TxOutputs" json:"TxOutputs"`
+	// TxnMeta holds the metadata specific to the transaction type as decoded by core
+	TxnMeta   interface{}       `bson:"TxnMeta" json:"TxnMeta"`
+	ExtraData map[string][]byte `bson:"ExtraData" json:"ExtraData"`
+}
+
+// BlockDoc is a block stored under _PrefixBlockHashToBlock
+type BlockDoc struct {
+	DocMeta   `bson:",inline" json:",inline"`
+	BlockHash string          `bson:"BlockHash" json:"BlockHash"`
+	Header    *BlockHeaderDoc `bson:"Header" json:"Header"`
+	Txns      []TxnDoc        `bson:"Txns" json:"Txns"`
+	// InMainChain and Orphaned are set by the SyncingService from the best chain tip
+	InMainChain *bool `bson:"InMainChain,omitempty" json:"InMainChain,omitempty"`
+	Orphaned    *bool `bson:"Orphaned,omitempty" json:"Orphaned,omitempty"`
+}
+
+// BlockNodeDoc is a block node stored under _PrefixHeightHashToNodeInfo
+// or _PrefixBitcoinHeightHashToNodeInfo
+type BlockNodeDoc struct {
+	DocMeta          `bson:",inline" json:",inline"`
+	Hash             *string         `bson:"Hash" json:"Hash"`
+	ParentHash       *string         `bson:"ParentHash" json:"ParentHash"`
+	Height           uint32          `bson:"Height" json:"Height"`
+	Header           *BlockHeaderDoc `bson:"Header" json:"Header"`
+	Status           uint32          `bson:"Status" json:"Status"`
+	CumWork          *string         `bson:"CumWork" json:"CumWork"`
+	DifficultyTarget *string         `bson:"DifficultyTarget" json:"DifficultyTarget"`
+	// InMainChain and Orphaned are set by the SyncingService from the best chain tip.
+	// Block nodes above the tip are neither.
+	InMainChain *bool `bson:"InMainChain,omitempty" json:"InMainChain,omitempty"`
+	Orphaned    *bool `bson:"Orphaned,omitempty" json:"Orphaned,omitempty"`
+}
+
+// UtxoKeyDoc identifies a UTXO by the transaction that created it and its output index
+type UtxoKeyDoc struct {
+	TxID  string `bson:"TxID" json:"TxID"`
+	Index uint32 `bson:"Index" json:"Index"`
+}
+
+// UtxoDoc is an unspent output stored under _PrefixUtxoKeyToUtxoEntry
+type UtxoDoc struct {
+	DocMeta     `bson:",inline" json:",inline"`
+	AmountNanos uint64      `bson:"AmountNanos" json:"AmountNanos"`
+	PublicKey   string      `bson:"PublicKey" json:"PublicKey"`
+	BlockHeight uint32      `bson:"BlockHeight" json:"BlockHeight"`
+	UtxoType    string      `bson:"UtxoType" json:"UtxoType"`
+	UtxoKey     *UtxoKeyDoc `bson:"UtxoKey" json:"UtxoKey"`
+}
+
+// PostDoc is a post or comment stored under _PrefixPostHashToPostEntry
+type PostDoc struct {
+	DocMeta                        `bson:",inline" json:",inline"`
+	PostHash                       *string `bson:"PostHash" json:"PostHash"`
+	PosterPublicKey                string  `bson:"PosterPublicKey" json:"PosterPublicKey"`
+	ParentStakeID                  string  `bson:"ParentStakeID" json:"ParentStakeID"`
+	Body                           string  `bson:"Body" json:"Body"`
+	RepostedPostHash               *string `bson:"RepostedPostHash" json:"RepostedPostHash"`
+	IsQuotedRepost                 bool    `bson:"IsQuotedRepost" json:"IsQuotedRepost"`
+	CreatorBasisPoints             uint64  `bson:"CreatorBasisPoints" json:"CreatorBasisPoints"`
+	StakeMultipleBasisPoints       uint64  `bson:"StakeMultipleBasisPoints" json:"StakeMultipleBasisPoints"`
+	ConfirmationBlockHeight        uint32  `bson:"ConfirmationBlockHeight" json:"ConfirmationBlockHeight"`
+	TimestampNanos                 uint64  `bson:"TimestampNanos" json:"TimestampNanos"`
+	IsHidden                       bool    `bson:"IsHidden" json:"IsHidden"`
+	LikeCount                      uint64  `bson:"LikeCount" json:"LikeCount"`
+	RepostCount                    uint64  `bson:"RepostCount" json:"RepostCount"`
+	QuoteRepostCount               uint64  `bson:"QuoteRepostCount" json:"QuoteRepostCount"`
+	DiamondCount                   uint64  `bson:"DiamondCount" json:"DiamondCount"`
+	CommentCount                   uint64  `bson:"CommentCount" json:"CommentCount"`
+	IsPinned                       bool    `bson:"IsPinned" json:"IsPinned"`
+	IsNFT                          bool    `bson:"IsNFT" json:"IsNFT"`
+	NumNFTCopies                   uint64  `bson:"NumNFTCopies" json:"NumNFTCopies"`
+	NumNFTCopiesForSale            uint64  `bson:"NumNFTCopiesForSale" json:"NumNFTCopiesForSale"`
+	HasUnlockable                  bool    `bson:"HasUnlockable" json:"HasUnlockable"`
+	NFTRoyaltyToCreatorBasisPoints uint64  `bson:"NFTRoyaltyToCreatorBasisPoints" json:"NFTRoyaltyToCreatorBasisPoints"`
+	NFTRoyaltyToCoinBasisPoints    uint64  `bson:"NFTRoyaltyToCoinBasisPoints" json:"NFTRoyaltyToCoinBasisPoints"`
+	// PostExtraData holds arbitrary data attached to the post by the app that created it
+	PostExtraData map[string][]byte `bson:"PostExtraData" json:"PostExtraData"`
+}
+
+// CoinEntryDoc is the state of a creator coin
+type CoinEntryDoc struct {
+	CreatorBasisPoints      uint64 `bson:"CreatorBasisPoints" json:"CreatorBasisPoints"`
+	DeSoLockedNanos         uint64 `bson:"DeSoLockedNanos" json:"DeSoLockedNanos"`
+	NumberOfHolders         uint64 `bson:"NumberOfHolders" json:"NumberOfHolders"`
+	CoinsInCirculationNanos uint64 `bson:"CoinsInCirculationNanos" json:"CoinsInCirculationNanos"`
+	CoinWatermarkNanos      uint64 `bson:"CoinWatermarkNanos" json:"CoinWatermarkNanos"`
+}
+
+// ProfileDoc is a user's profile stored under _PrefixPKIDToProfileEntry
+type ProfileDoc struct {
+	DocMeta     `bson:",inline" json:",inline"`
+	PublicKey   string       `bson:"PublicKey" json:"PublicKey"`
+	Username    string       `bson:"Username" json:"Username"`
+	Description string       `bson:"Description" json:"Description"`
+	ProfilePic  string       `bson:"ProfilePic" json:"ProfilePic"`
+	IsHidden    bool         `bson:"IsHidden" json:"IsHidden"`
+	CoinEntry   CoinEntryDoc `bson:"CoinEntry" json:"CoinEntry"`
+}
+
+// FollowDoc is a follow stored under _PrefixFollowerPKIDToFollowedPKID
+// or _PrefixFollowedPKIDToFollowerPKID
+type FollowDoc struct {
+	DocMeta      `bson:",inline" json:",inline"`
+	FollowerPKID string `bson:"FollowerPKID" json:"FollowerPKID"`
+	FollowedPKID string `bson:"FollowedPKID" json:"FollowedPKID"`
+}
+
+// LikeDoc is a like stored under _PrefixLikerPubKeyToLikedPostHash
+// or _PrefixLikedPostHashToLikerPubKey
+type LikeDoc struct {
+	DocMeta       `bson:",inline" json:",inline"`
+	PublicKey     string `bson:"PublicKey" json:"PublicKey"`
+	LikedPostHash string `bson:"LikedPostHash" json:"LikedPostHash"`
+}
+
+// BalanceDoc is a user's balance of a creator coin or DAO coin stored under
+// _PrefixHODLerPKIDCreatorPKIDToBalanceEntry, _PrefixCreatorPKIDHODLerPKIDToBalanceEntry
+// or their DAO coin counterparts
+type BalanceDoc struct {
+	DocMeta      `bson:",inline" json:",inline"`
+	HODLerPKID   *string `bson:"HODLerPKID" json:"HODLerPKID"`
+	CreatorPKID  *string `bson:"CreatorPKID" json:"CreatorPKID"`
+	BalanceNanos uint64  `bson:"BalanceNanos" json:"BalanceNanos"`
+	HasPurchased bool    `bson:"HasPurchased" json:"HasPurchased"`
+}
+
+// DiamondDoc is a diamond given on a post stored under
+// _PrefixDiamondReceiverPKIDDiamondSenderPKIDPostHash or
+// _PrefixDiamondSenderPKIDDiamondReceiverPKIDPostHash
+type DiamondDoc struct {
+	DocMeta         `bson:",inline" json:",inline"`
+	SenderPKID      *string `bson:"SenderPKID" json:"SenderPKID"`
+	ReceiverPKID    *string `bson:"ReceiverPKID" json:"ReceiverPKID"`
+	DiamondPostHash *string `bson:"DiamondPostHash" json:"DiamondPostHash"`
+	DiamondLevel    int64   `bson:"DiamondLevel" json:"DiamondLevel"`
+}
+
+// NFTDoc is a serial number of an NFT stored under _PrefixPostHashSerialNumberToNFTEntry
+// or _PrefixPKIDIsForSaleBidAmountNanosPostHashSerialNumberToNFTEntry
+type NFTDoc struct {
+	DocMeta                    `bson:",inline" json:",inline"`
+	LastOwnerPKID              *string `bson:"LastOwnerPKID" json:"LastOwnerPKID"`
+	OwnerPKID                  *string `bson:"OwnerPKID" json:"OwnerPKID"`
+	NFTPostHash                *string `bson:"NFTPostHash" json:"NFTPostHash"`
+	SerialNumber               uint64  `bson:"SerialNumber" json:"SerialNumber"`
+	IsForSale                  bool    `bson:"IsForSale" json:"IsForSale"`
+	MinBidAmountNanos          uint64  `bson:"MinBidAmountNanos" json:"MinBidAmountNanos"`
+	UnlockableText             string  `bson:"UnlockableText" json:"UnlockableText"`
+	LastAcceptedBidAmountNanos uint64  `bson:"LastAcceptedBidAmountNanos" json:"LastAcceptedBidAmountNanos"`
+	IsPending                  bool    `bson:"IsPending" json:"IsPending"`
+}
+
+// NFTBidDoc is a bid on a serial number of an NFT stored under
+// _PrefixPostHashSerialNumberBidNanosBidderPKID
+type NFTBidDoc struct {
+	DocMeta             `bson:",inline" json:",inline"`
+	BidderPKID          *string `bson:"BidderPKID" json:"BidderPKID"`
+	NFTPostHash         *string `bson:"NFTPostHash" json:"NFTPostHash"`
+	SerialNumber        uint64  `bson:"SerialNumber" json:"SerialNumber"`
+	BidAmountNanos      uint64  `bson:"BidAmountNanos" json:"BidAmountNanos"`
+	AcceptedBlockHeight *uint32 `bson:"AcceptedBlockHeight" json:"AcceptedBlockHeight"`
+}
+
+// DocTypes lists the typed documents, for which a JSON Schema is published
+var DocTypes = []interface{}{
+	BlockDoc{},
+	BlockNodeDoc{},
+	UtxoDoc{},
+	PostDoc{},
+	ProfileDoc{},
+	FollowDoc{},
+	LikeDoc{},
+	BalanceDoc{},
+	DiamondDoc{},
+	NFTDoc{},
+	NFTBidDoc{},
+}
+
+// Initializes and returns a DocMeta
+func newDocMeta(mongoMeta string, badgerKeyPrefix string) DocMeta {
+	return DocMeta{
+		MongoMeta:       mongoMeta,
+		BadgerKeyPrefix: badgerKeyPrefix,
+	}
+}
+
+// Returns the hex encoding of hash, or nil if it's not set
+func optionalBlockHash(hash *lib.BlockHash) *string {
+	if hash == nil {
+		return nil
+	}
+	hashString := hash.String()
+	return &hashString
+}
+
+// Returns the Base58Check encoding of pkid, or nil if it's not set
+func optionalPKID(pkid *lib.PKID) *string {
+	if pkid == nil {
+		return nil
+	}
+	pkidString := lib.PkToStringBoth(pkid[:])
+	return &pkidString
+}
+
+// Converts a block header into a BlockHeaderDoc
+func newBlockHeaderDoc(header *lib.MsgDeSoHeader) *BlockHeaderDoc {
+	if header == nil {
+		return nil
+	}
+	return &BlockHeaderDoc{
+		Version:               header.Version,
+		PrevBlockHash:         optionalBlockHash(header.PrevBlockHash),
+		TransactionMerkleRoot: optionalBlockHash(header.TransactionMerkleRoot),
+		TstampSecs:            header.TstampSecs,
+		Height:                header.Height,
+		Nonce:                 header.Nonce,
+		ExtraNonce:            header.ExtraNonce,
+	}
+}
+
+// Converts a transaction into a TxnDoc
+func newTxnDoc(txn *lib.MsgDeSoTxn) TxnDoc {
+	txnDoc := TxnDoc{
+		TxnHash:   optionalBlockHash(txn.Hash()),
+		PublicKey: lib.PkToStringBoth(txn.PublicKey),
+		TxnMeta:   txn.TxnMeta,
+		ExtraData: txn.ExtraData,
+	}
+	if txn.TxnMeta != nil {
+		txnDoc.TxnType = txn.TxnMeta.GetTxnType().String()
+	}
+	for _, input := range txn.TxInputs {
+		if input != nil {
+			txnDoc.TxInputs = append(txnDoc.TxInputs, TxnInputDoc{TxID: input.TxID.String(), Index: input.Index})
+		}
+	}
+	for _, output := range txn.TxOutputs {
+		if output != nil {
+			txnDoc.TxOutputs = append(txnDoc.TxOutputs, TxnOutputDoc{
+				PublicKey:   lib.PkToStringBoth(output.PublicKey),
+				AmountNanos: output.AmountNanos,
+			})
+		}
+	}
+	return txnDoc
+}
+
+// Converts a block node into a BlockNodeDoc
+func newBlockNodeDoc(node *lib.BlockNode, mongoMeta string, badgerKeyPrefix string) BlockNodeDoc {
+	doc := BlockNodeDoc{
+		DocMeta:          newDocMeta(mongoMeta, badgerKeyPrefix),
+		Hash:             optionalBlockHash(node.Hash),
+		Height:           node.Height,
+		Header:           newBlockHeaderDoc(node.Header),
+		Status:           uint32(node.Status),
+		DifficultyTarget: optionalBlockHash(node.DifficultyTarget),
+	}
+	if node.Parent != nil {
+		doc.ParentHash = optionalBlockHash(node.Parent.Hash)
+	}
+	if node.CumWork != nil {
+		cumWork := node.CumWork.String()
+		doc.CumWork = &cumWork
+	}
+	return doc
+}
+
+// Converts a creator coin or DAO coin balance entry into a BalanceDoc
+func newBalanceDoc(entry *lib.BalanceEntry, mongoMeta string, badgerKeyPrefix string) BalanceDoc {
+	return BalanceDoc{
+		DocMeta:      newDocMeta(mongoMeta, badgerKeyPrefix),
+		HODLerPKID:   optionalPKID(entry.HODLerPKID),
+		CreatorPKID:  optionalPKID(entry.CreatorPKID),
+		BalanceNanos: entry.BalanceNanos,
+		HasPurchased: entry.HasPurchased,
+	}
+}
+
+// Converts an NFT entry into an NFTDoc
+func newNFTDoc(entry *lib.NFTEntry, mongoMeta string, badgerKeyPrefix string) NFTDoc {
+	return NFTDoc{
+		DocMeta:                    newDocMeta(mongoMeta, badgerKeyPrefix),
+		LastOwnerPKID:              optionalPKID(entry.LastOwnerPKID),
+		OwnerPKID:                  optionalPKID(entry.OwnerPKID),
+		NFTPostHash:                optionalBlockHash(entry.NFTPostHash),
+		SerialNumber:               entry.SerialNumber,
+		IsForSale:                  entry.IsForSale,
+		MinBidAmountNanos:          entry.MinBidAmountNanos,
+		UnlockableText:             string(entry.UnlockableText),
+		LastAcceptedBidAmountNanos: entry.LastAcceptedBidAmountNanos,
+		IsPending:                  entry.IsPending,
+	}
+}
+
+// Converts a typed document into a map keyed by the fields' bson tags, so
+// that it is written like the documents decoders build as maps directly
+func docToMap(doc interface{}) map[string]interface{} {
+	docMap, _ := docValueToInterface(reflect.ValueOf(doc)).(map[string]interface{})
+	return docMap
+}
+
+// Converts structs within a typed document into maps
+func docValueToInterface(val reflect.Value) interface{} {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			return nil
+		}
+		return docValueToInterface(val.Elem())
+	case reflect.Interface:
+		if val.IsNil() {
+			return nil
+		}
+		// Values of core types are converted when the record is encoded
+		return val.Elem().Interface()
+	case reflect.Struct:
+		if val.Type() == reflect.TypeOf(time.Time{}) {
+			return val.Interface()
+		}
+		docMap := make(map[string]interface{})
+		addDocFields(val, docMap)
+		return docMap
+	case reflect.Slice:
+		if val.IsNil() {
+			return nil
+		}
+		if val.Type().Elem().Kind() == reflect.Uint8 {
+			return val.Interface()
+		}
+		arr := make([]interface{}, val.Len())
+		for i := range arr {
+			arr[i] = docValueToInterface(val.Index(i))
+		}
+		return arr
+	case reflect.Map:
+		if val.IsNil() {
+			return nil
+		}
+		if val.Type().Key().Kind() != reflect.String {
+			return val.Interface()
+		}
+		// Values are converted like fields, so that byte slices stay binary
+		docMap := make(map[string]interface{}, val.Len())
+		itr := val.MapRange()
+		for itr.Next() {
+			docMap[itr.Key().String()] = docValueToInterface(itr.Value())
+		}
+		return docMap
+	}
+	return val.Interface()
+}
+
+// Adds the exported fields of the struct val to docMap
+func addDocFields(val reflect.Value, docMap map[string]interface{}) {
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, inline, omitEmpty := parseBSONTag(field)
+		switch {
+		case name == "-":
+			continue
+		case inline:
+			addDocFields(val.Field(i), docMap)
+		case omitEmpty && val.Field(i).IsZero():
+			continue
+		default:
+			docMap[name] = docValueToInterface(val.Field(i))
+		}
+	}
+}
+
+// Returns the document field name of a struct field and whether
+// it is inlined or omitted when empty according to its bson tag
+func parseBSONTag(field reflect.StructField) (string, bool, bool) {
+	parts := strings.Split(field.Tag.Get("bson"), ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	inline, omitEmpty := false, false
+	for _, option := range parts[1:] {
+		switch option {
+		case "inline":
+			inline = true
+		case "omitempty":
+			omitEmpty = true
+		}
+	}
+	return name, inline, omitEmpty
+}
+
+// Returns the hex encoding of a ParentStakeID, which is empty for top level posts
+func stakeIDToString(stakeID []byte) string {
+	return hex.EncodeToString(stakeID)
+}
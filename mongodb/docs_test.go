@@ -0,0 +1,21 @@
+package mongodb
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDocToMapKeepsExtraDataBinary(t *testing.T) {
+	doc := toBSONValue(docToMap(PostDoc{PostExtraData: map[string][]byte{"App": []byte("gm")}})).(bson.M)
+	extraData, ok := doc["PostExtraData"].(bson.M)
+	if !ok {
+		t.Fatalf("Got PostExtraData %#v, want a document", doc["PostExtraData"])
+	}
+	if val, ok := extraData["App"].([]byte); !ok || string(val) != "gm" {
+		t.Errorf("Got PostExtraData value %#v, want the raw bytes", extraData["App"])
+	}
+	if doc := docToMap(PostDoc{}); doc["PostExtraData"] != nil {
+		t.Errorf("Got PostExtraData %#v for a post without extra data, want nil", doc["PostExtraData"])
+	}
+}
@@ -0,0 +1,351 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// This file contains the history mode of the MongoSink, which keeps the previous
+// versions of documents in a history collection next to their collection
+
+// Field holding the block height from which a document's content is valid in history mode
+const validFromHeightField = "ValidFromHeight"
+
+// Suffix of the collection holding the previous versions of a collection's documents
+const historyCollectionSuffix = "_history"
+
+// DefaultHistoryPrefixes are the badgerDB key prefixes whose previous versions are
+// kept in history mode: posts, profiles, and creator coin and DAO coin balances
+var DefaultHistoryPrefixes = []byte{17, 23, 33, 55}
+
+// Returns the set of prefixes whose previous versions are kept in history mode
+func ParseHistoryPrefixes(prefixes []int) (map[byte]bool, error) {
+	historyPrefixes := make(map[byte]bool, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix < 0 || prefix > 255 {
+			return nil, fmt.Errorf("Invalid history prefix %d", prefix)
+		}
+		historyPrefixes[byte(prefix)] = true
+	}
+	return historyPrefixes, nil
+}
+
+// HistoryEntry is a previous version of a document. The version was the
+// document's content from block height ValidFromHeight up to, but excluding,
+// ValidToHeight.
+type HistoryEntry struct {
+	// ID is the document's _id followed by @ and ValidFromHeight
+	ID string `bson:"_id" json:"_id"`
+	// DocumentID is the _id of the document the version belongs to
+	DocumentID      string `bson:"DocumentID" json:"DocumentID"`
+	ValidFromHeight uint64 `bson:"ValidFromHeight" json:"ValidFromHeight"`
+	ValidToHeight   uint64 `bson:"ValidToHeight" json:"ValidToHeight"`
+	// Deleted is true if the version ended because its badgerDB key was deleted
+	Deleted bool `bson:"Deleted" json:"Deleted"`
+	// Doc holds the version's document without its _id
+	Doc bson.M `bson:"Doc" json:"Doc"`
+	// ArchivedAt holds the wall-clock time the version was archived
+	ArchivedAt time.Time `bson:"ArchivedAt" json:"ArchivedAt"`
+}
+
+// Initializes and returns the HistoryEntry archiving oldDoc, which ends at validToHeight
+func newHistoryEntry(oldDoc bson.M, validToHeight uint64, deleted bool) *HistoryEntry {
+	id, _ := oldDoc["_id"].(string)
+	doc := make(bson.M, len(oldDoc))
+	for field, val := range oldDoc {
+		if field != "_id" {
+			doc[field] = val
+		}
+	}
+	validFromHeight := versionStartHeight(oldDoc)
+	return &HistoryEntry{
+		ID:              fmt.Sprintf("%s@%d", id, validFromHeight),
+		DocumentID:      id,
+		ValidFromHeight: validFromHeight,
+		ValidToHeight:   validToHeight,
+		Deleted:         deleted,
+		Doc:             doc,
+		ArchivedAt:      time.Now(),
+	}
+}
+
+// Returns the height from which the version in doc is known to be valid. Documents
+// written before history mode was enabled fall back to the tip they were written at.
+func versionStartHeight(doc bson.M) uint64 {
+	if height, ok := heightValue(doc[validFromHeightField]); ok {
+		return height
+	}
+	if height, ok := heightValue(doc[tipHeightField]); ok {
+		return height
+	}
+	return 0
+}
+
+// Converts a block height read from a document into a uint64
+func heightValue(val interface{}) (uint64, bool) {
+	switch height := val.(type) {
+	case int32:
+		return uint64(height), height >= 0
+	case int64:
+		return uint64(height), height >= 0
+	case float64:
+		return uint64(height), height >= 0
+	}
+	return 0, false
+}
+
+// Sets the height of the best chain tip the records written next were read at.
+// History mode uses it as the height at which changed or deleted versions end.
+func (sink *MongoSink) SetChainTip(height uint64) {
+	sink.chainTipHeight = height
+}
+
+// Returns true if previous versions of the document for key are kept
+func (sink *MongoSink) historyEnabled(key []byte) bool {
+	return len(key) != 0 && sink.historyPrefixes[key[0]] && sink.chainTipHeight != 0
+}
+
+// Returns the collection holding the previous versions of the named collection's documents
+func (sink *MongoSink) historyCollection(name string) *mongo.Collection {
+	return sink.mongoClient.Database(sink.mongoDBName).Collection(name + historyCollectionSuffix)
+}
+
+// Creates the index used to find the version of a document at a height
+func (sink *MongoSink) createHistoryIndexes() {
+	seen := make(map[string]bool)
+	for prefix := range sink.historyPrefixes {
+		name := sink.collectionName([]byte{prefix})
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		_, err := sink.historyCollection(name).Indexes().CreateOne(context.Background(), mongo.IndexModel{
+			Keys: bson.D{{Key: "DocumentID", Value: 1}, {Key: "ValidFromHeight", Value: 1}},
+		})
+		if err != nil {
+			fmt.Printf("Failed to create index on %v%v: %v\n", name, historyCollectionSuffix, err)
+		}
+	}
+}
+
+// Reads the documents with ids from the named collection, keyed by _id
+func (sink *MongoSink) findDocuments(name string, ids []string) (map[string]bson.M, error) {
+	collection := sink.mongoClient.Database(sink.mongoDBName).Collection(name)
+	cursor, err := collection.Find(context.Background(), bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	docs := make(map[string]bson.M, len(ids))
+	for cursor.Next(context.Background()) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		if id, ok := doc["_id"].(string); ok {
+			docs[id] = doc
+		}
+	}
+	return docs, cursor.Err()
+}
+
+// Groups the keys kept in history by the collection holding their documents
+func (sink *MongoSink) historyKeysByCollection(keys [][]byte) map[string][][]byte {
+	keysByCollection := make(map[string][][]byte)
+	for _, key := range keys {
+		if sink.historyEnabled(key) {
+			name := sink.collectionName(key)
+			keysByCollection[name] = append(keysByCollection[name], key)
+		}
+	}
+	return keysByCollection
+}
+
+// Reads the current versions of the documents for keys from the named collection
+func (sink *MongoSink) findCurrentVersions(name string, keys [][]byte) (map[string]bson.M, error) {
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		ids[i] = documentID(key)
+	}
+	return sink.findDocuments(name, ids)
+}
+
+// Archives the current version of every record kept in history whose content
+// differs from the record. Returns the ValidFromHeight of each such record's new
+// version and the records whose previous version couldn't be archived.
+func (sink *MongoSink) archiveChangedVersions(records []*Record) (map[string]uint64, map[string]error) {
+	validFromHeights := make(map[string]uint64)
+	failedKeys := make(map[string]error)
+
+	docsByKey := make(map[string]map[string]interface{}, len(records))
+	keys := make([][]byte, len(records))
+	for i, record := range records {
+		docsByKey[string(record.Key)] = record.Doc
+		keys[i] = record.Key
+	}
+
+	for name, historyKeys := range sink.historyKeysByCollection(keys) {
+		oldDocs, err := sink.findCurrentVersions(name, historyKeys)
+		if err != nil {
+			for _, key := range historyKeys {
+				failedKeys[string(key)] = newOperationWriteError(name, key,
+					fmt.Errorf("Failed to read previous version: %w", err))
+			}
+			continue
+		}
+
+		var entries []*HistoryEntry
+		var entryKeys [][]byte
+		for _, key := range historyKeys {
+			oldDoc, exists := oldDocs[documentID(key)]
+			if !exists || oldDoc[deletedAtField] != nil {
+				// The deleted version was archived when it was deleted
+				validFromHeights[string(key)] = sink.chainTipHeight
+				continue
+			}
+
+			same, err := sameContent(oldDoc, docsByKey[string(key)])
+			if err != nil {
+				failedKeys[string(key)] = err
+				continue
+			}
+			if same {
+				validFromHeights[string(key)] = versionStartHeight(oldDoc)
+				if _, ok := heightValue(oldDoc[validFromHeightField]); !ok {
+					validFromHeights[string(key)] = sink.chainTipHeight
+				}
+				continue
+			}
+
+			validFromHeights[string(key)] = sink.chainTipHeight
+			entries = append(entries, newHistoryEntry(oldDoc, sink.chainTipHeight, false))
+			entryKeys = append(entryKeys, key)
+		}
+
+		for key, err := range sink.writeHistoryEntries(name, entries, entryKeys) {
+			failedKeys[key] = err
+		}
+	}
+
+	return validFromHeights, failedKeys
+}
+
+// Archives the current version of every document kept in history whose
+// badgerDB key was deleted. Returns the keys whose version couldn't be archived.
+func (sink *MongoSink) archiveDeletedVersions(keys [][]byte) map[string]error {
+	failedKeys := make(map[string]error)
+	for name, historyKeys := range sink.historyKeysByCollection(keys) {
+		oldDocs, err := sink.findCurrentVersions(name, historyKeys)
+		if err != nil {
+			for _, key := range historyKeys {
+				failedKeys[string(key)] = newOperationWriteError(name, key,
+					fmt.Errorf("Failed to read previous version: %w", err))
+			}
+			continue
+		}
+
+		var entries []*HistoryEntry
+		var entryKeys [][]byte
+		for _, key := range historyKeys {
+			oldDoc, exists := oldDocs[documentID(key)]
+			if !exists || oldDoc[deletedAtField] != nil {
+				continue
+			}
+			entries = append(entries, newHistoryEntry(oldDoc, sink.chainTipHeight, true))
+			entryKeys = append(entryKeys, key)
+		}
+
+		for key, err := range sink.writeHistoryEntries(name, entries, entryKeys) {
+			failedKeys[key] = err
+		}
+	}
+	return failedKeys
+}
+
+// Writes entries to the history collection of the named collection. keys holds
+// the badgerDB key of each entry. Returns the keys whose entry failed to be written.
+func (sink *MongoSink) writeHistoryEntries(name string, entries []*HistoryEntry, keys [][]byte) map[string]error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	historyName := name + historyCollectionSuffix
+	var ops []mongo.WriteModel
+	for _, entry := range entries {
+		op := mongo.NewReplaceOneModel()
+		op.SetFilter(bson.M{"_id": entry.ID})
+		op.SetReplacement(entry)
+		op.SetUpsert(true)
+		ops = append(ops, op)
+	}
+
+	err := sink.executeBulkWrite(map[string][]mongo.WriteModel{historyName: ops},
+		map[string][][]byte{historyName: keys})
+	if batchErr, ok := err.(*BatchWriteError); ok {
+		return batchErr.FailedKeys
+	}
+	return nil
+}
+
+// Returns true if the two documents hold the same content, ignoring sync metadata
+func sameContent(oldDoc bson.M, newDoc map[string]interface{}) (bool, error) {
+	oldContent, err := canonicalContent(oldDoc)
+	if err != nil {
+		return false, err
+	}
+	newContent, err := canonicalContent(newDoc)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(oldContent, newContent), nil
+}
+
+// Returns the document for key as it was at block height, or nil if it didn't
+// exist then. Versions older than the history kept for the key aren't known.
+func (sink *MongoSink) FindAsOfHeight(key []byte, height uint64) (bson.M, error) {
+	name := sink.collectionName(key)
+	id := documentID(key)
+
+	var doc bson.M
+	err := sink.mongoClient.Database(sink.mongoDBName).Collection(name).FindOne(
+		context.Background(), bson.M{"_id": id}).Decode(&doc)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	current := err == nil && doc[deletedAtField] == nil
+	if current {
+		if validFromHeight, ok := heightValue(doc[validFromHeightField]); ok && validFromHeight <= height {
+			return doc, nil
+		}
+	}
+
+	var entry HistoryEntry
+	err = sink.historyCollection(name).FindOne(context.Background(), bson.M{
+		"DocumentID":      id,
+		"ValidFromHeight": bson.M{"$lte": height},
+		"ValidToHeight":   bson.M{"$gt": height},
+	}, options.FindOne().SetSort(bson.M{"ValidFromHeight": -1})).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		if current {
+			if _, ok := heightValue(doc[validFromHeightField]); !ok {
+				// The document was written before history mode was enabled
+				return doc, nil
+			}
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Doc["_id"] = id
+	return entry.Doc, nil
+}
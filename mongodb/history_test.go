@@ -0,0 +1,155 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewHistoryEntry(t *testing.T) {
+	oldDoc := bson.M{
+		"_id":                "11ab",
+		"Body":               "gm",
+		validFromHeightField: int64(10),
+		tipHeightField:       int64(12),
+	}
+	entry := newHistoryEntry(oldDoc, 20, true)
+	if entry.ID != "11ab@10" || entry.DocumentID != "11ab" {
+		t.Errorf("Got _id %v for document %v, want 11ab@10 for 11ab", entry.ID, entry.DocumentID)
+	}
+	if entry.ValidFromHeight != 10 || entry.ValidToHeight != 20 || !entry.Deleted {
+		t.Errorf("Got version from %d to %d, deleted %v, want from 10 to 20, deleted",
+			entry.ValidFromHeight, entry.ValidToHeight, entry.Deleted)
+	}
+	if _, exists := entry.Doc["_id"]; exists || entry.Doc["Body"] != "gm" {
+		t.Errorf("Got archived document %v, want the document without its _id", entry.Doc)
+	}
+}
+
+func TestVersionStartHeight(t *testing.T) {
+	tests := []struct {
+		doc  bson.M
+		want uint64
+	}{
+		{bson.M{validFromHeightField: int64(10), tipHeightField: int64(12)}, 10},
+		// Documents written before history mode was enabled
+		{bson.M{tipHeightField: int32(12)}, 12},
+		{bson.M{tipHeightField: float64(12)}, 12},
+		{bson.M{tipHeightField: int64(-1)}, 0},
+		{bson.M{}, 0},
+	}
+	for _, test := range tests {
+		if got := versionStartHeight(test.doc); got != test.want {
+			t.Errorf("versionStartHeight(%v) = %d, want %d", test.doc, got, test.want)
+		}
+	}
+}
+
+// Returns a MongoSink keeping the history of posts in a new database on the
+// mongoDB server at MONGODB_TEST_URI. Skips the test if it isn't set.
+func newHistoryTestSink(t *testing.T) *MongoSink {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI isn't set")
+	}
+	dbName := fmt.Sprintf("mongodb_dumper_test_%d", time.Now().UnixNano())
+	sink := NewMongoSink(uri, dbName, "badger", "metadata", "dead_letters", DefaultPrefixCollections,
+		false, map[byte]bool{17: true})
+	if err := sink.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sink.mongoClient.Database(dbName).Drop(context.Background())
+		sink.Close()
+	})
+	return sink
+}
+
+// Upserts a post with body for key at the chain tip height
+func upsertTestPost(t *testing.T, sink *MongoSink, key []byte, body string, height uint64) {
+	sink.SetChainTip(height)
+	record := &Record{Key: key, Doc: map[string]interface{}{"Body": body, tipHeightField: int64(height)}}
+	if err := sink.UpsertBatch([]*Record{record}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchiveChangedVersions(t *testing.T) {
+	sink := newHistoryTestSink(t)
+	key := []byte{17, 1}
+	upsertTestPost(t, sink, key, "v1", 10)
+
+	// Unchanged content keeps the start height of its version
+	sink.SetChainTip(20)
+	unchanged := &Record{Key: key, Doc: map[string]interface{}{"Body": "v1", tipHeightField: int64(20)}}
+	validFromHeights, failedKeys := sink.archiveChangedVersions([]*Record{unchanged})
+	if len(failedKeys) != 0 || validFromHeights[string(key)] != 10 {
+		t.Errorf("Got start height %d and failures %v for unchanged content, want 10",
+			validFromHeights[string(key)], failedKeys)
+	}
+
+	// Changed content starts a new version and archives the previous one
+	changed := &Record{Key: key, Doc: map[string]interface{}{"Body": "v2", tipHeightField: int64(20)}}
+	validFromHeights, failedKeys = sink.archiveChangedVersions([]*Record{changed})
+	if len(failedKeys) != 0 || validFromHeights[string(key)] != 20 {
+		t.Errorf("Got start height %d and failures %v for changed content, want 20",
+			validFromHeights[string(key)], failedKeys)
+	}
+	var entry HistoryEntry
+	err := sink.historyCollection(sink.collectionName(key)).FindOne(context.Background(),
+		bson.M{"_id": documentID(key) + "@10"}).Decode(&entry)
+	if err != nil {
+		t.Fatalf("Previous version wasn't archived: %v", err)
+	}
+	if entry.ValidToHeight != 20 || entry.Doc["Body"] != "v1" {
+		t.Errorf("Got archived version %+v, want v1 valid up to 20", entry)
+	}
+}
+
+func TestFindAsOfHeight(t *testing.T) {
+	sink := newHistoryTestSink(t)
+	key := []byte{17, 2}
+	upsertTestPost(t, sink, key, "v1", 10)
+	upsertTestPost(t, sink, key, "v2", 20)
+	upsertTestPost(t, sink, key, "v2", 30)
+
+	tests := []struct {
+		height uint64
+		want   interface{}
+	}{
+		{5, nil},
+		{10, "v1"},
+		{19, "v1"},
+		{20, "v2"},
+		{35, "v2"},
+	}
+	for _, test := range tests {
+		doc, err := sink.FindAsOfHeight(key, test.height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body interface{}
+		if doc != nil {
+			body = doc["Body"]
+		}
+		if body != test.want {
+			t.Errorf("Got %v at height %d, want %v", body, test.height, test.want)
+		}
+	}
+
+	// A deleted document is found up to its deletion
+	sink.SetChainTip(40)
+	if err := sink.DeleteBatch([][]byte{key}); err != nil {
+		t.Fatal(err)
+	}
+	if doc, err := sink.FindAsOfHeight(key, 35); err != nil || doc == nil || doc["Body"] != "v2" {
+		t.Errorf("Got %v (%v) at height 35 after the deletion, want v2", doc, err)
+	}
+	if doc, err := sink.FindAsOfHeight(key, 45); err != nil || doc != nil {
+		t.Errorf("Got %v (%v) at height 45 after the deletion, want nothing", doc, err)
+	}
+}
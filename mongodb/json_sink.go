@@ -0,0 +1,157 @@
+package mongodb
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// This file contains a Sink implementation writing newline delimited JSON
+
+// JSONSink writes every upsert and delete as one line of JSON to a file
+// or stdout. It keeps its checkpoint in memory only, so a restarted dumper
+// writing to a JSONSink always syncs from scratch.
+type JSONSink struct {
+	// path holds the file to append to, or "-" for stdout
+	path string
+	// file and writer are the open output file and its buffer
+	file   *os.File
+	writer *bufio.Writer
+	// checkpoint holds the last saved checkpoint
+	checkpoint *SyncCheckpoint
+	// lock serializes the writes of concurrent batches
+	lock sync.Mutex
+}
+
+// jsonSinkLine is a single line of JSONSink output. Doc holds the
+// document as relaxed extended JSON so that BSON types survive.
+type jsonSinkLine struct {
+	Op  string          `json:"Op"`
+	Key string          `json:"Key"`
+	Doc json.RawMessage `json:"Doc,omitempty"`
+}
+
+// Initializes and returns a new JSONSink writing to path, or stdout if path is "-"
+func NewJSONSink(path string) *JSONSink {
+	return &JSONSink{
+		path: path,
+	}
+}
+
+// Opens the output file
+func (sink *JSONSink) Connect(ctx context.Context) error {
+	sink.file = os.Stdout
+	if sink.path != "-" && sink.path != "" {
+		file, err := os.OpenFile(sink.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		sink.file = file
+	}
+	sink.writer = bufio.NewWriter(sink.file)
+	return nil
+}
+
+// Flushes and closes the output file
+func (sink *JSONSink) Close() error {
+	if sink.writer == nil {
+		return nil
+	}
+	if err := sink.writer.Flush(); err != nil {
+		return err
+	}
+	if sink.file == os.Stdout {
+		return nil
+	}
+	return sink.file.Close()
+}
+
+// Writes line followed by a newline
+func (sink *JSONSink) writeLine(line *jsonSinkLine) error {
+	lineJSON, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	if _, err = sink.writer.Write(lineJSON); err != nil {
+		return err
+	}
+	return sink.writer.WriteByte('\n')
+}
+
+// Writes an "upsert" line for every record, stamped with its ContentHash and
+// a LastChanged time of the write. FirstSeen is left to the reader, since the
+// output holds every write of a key.
+func (sink *JSONSink) UpsertBatch(records []*Record) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	now := time.Now()
+	for _, record := range records {
+		doc := make(map[string]interface{}, len(record.Doc)+2)
+		for field, val := range record.Doc {
+			doc[field] = val
+		}
+		if hash, err := contentHash(record.Doc); err == nil {
+			doc[contentHashField] = hex.EncodeToString(hash)
+		}
+		doc[lastChangedField] = now
+		docJSON, err := bson.MarshalExtJSON(doc, false, false)
+		if err != nil {
+			return err
+		}
+		err = sink.writeLine(&jsonSinkLine{Op: "upsert", Key: hex.EncodeToString(record.Key), Doc: docJSON})
+		if err != nil {
+			return err
+		}
+	}
+	return sink.writer.Flush()
+}
+
+// Writes a "delete" line for every key
+func (sink *JSONSink) DeleteBatch(keys [][]byte) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	for _, key := range keys {
+		if err := sink.writeLine(&jsonSinkLine{Op: "delete", Key: hex.EncodeToString(key)}); err != nil {
+			return err
+		}
+	}
+	return sink.writer.Flush()
+}
+
+// JSON output can't be enumerated, so deleted keys are only
+// reported as they are seen by the SyncingService
+func (sink *JSONSink) ForEachKey(fn func(key []byte) error) error {
+	return nil
+}
+
+func (sink *JSONSink) GetCheckpoint() (*SyncCheckpoint, error) {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	return sink.checkpoint, nil
+}
+
+func (sink *JSONSink) SaveCheckpoint(checkpoint *SyncCheckpoint) error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	checkpointCopy := *checkpoint
+	sink.checkpoint = &checkpointCopy
+	return nil
+}
+
+func (sink *JSONSink) DeleteCheckpoint() error {
+	sink.lock.Lock()
+	defer sink.lock.Unlock()
+
+	sink.checkpoint = nil
+	return nil
+}
@@ -0,0 +1,49 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONSinkStampsChangeMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.jsonl")
+	sink := NewJSONSink(path)
+	if err := sink.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	record := &Record{Key: []byte{1}, Doc: map[string]interface{}{"Body": "gm"}}
+	if err := sink.UpsertBatch([]*Record{record}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var line struct {
+		Doc map[string]interface{}
+	}
+	if err := json.Unmarshal(output, &line); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := contentHash(record.Doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line.Doc[contentHashField] != hex.EncodeToString(hash) {
+		t.Errorf("Got ContentHash %v, want %x", line.Doc[contentHashField], hash)
+	}
+	if line.Doc[lastChangedField] == nil {
+		t.Error("The upsert isn't stamped with LastChanged")
+	}
+	if _, ok := record.Doc[contentHashField]; ok {
+		t.Error("The record's document was modified")
+	}
+}
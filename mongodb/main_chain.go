@@ -0,0 +1,196 @@
+package mongodb
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/deso-protocol/core/lib"
+	"github.com/dgraph-io/badger/v3"
+)
+
+// This file contains the tracking of the best DeSo chain, used to tell
+// blocks on the main chain apart from blocks of orphaned forks
+
+// mainChain holds the hashes of the blocks on the best DeSo chain, indexed by height
+type mainChain struct {
+	hashes []lib.BlockHash
+}
+
+// Returns the _PrefixBlockHashToBlock key of the block with hash
+func blockKey(hash lib.BlockHash) []byte {
+	return append([]byte{0}, hash[:]...)
+}
+
+// Returns the _PrefixHeightHashToNodeInfo key of the block node with hash at height
+func blockNodeKey(height uint32, hash lib.BlockHash) []byte {
+	key := make([]byte, 37)
+	key[0] = 1
+	binary.BigEndian.PutUint32(key[1:5], height)
+	copy(key[5:], hash[:])
+	return key
+}
+
+// Returns the height of the main chain's tip, or -1 if it's empty
+func (chain *mainChain) tipHeight() int64 {
+	return int64(len(chain.hashes)) - 1
+}
+
+// Returns true if the block with hash at height is on the main chain
+func (chain *mainChain) contains(height uint64, hash lib.BlockHash) bool {
+	return height < uint64(len(chain.hashes)) && chain.hashes[height] == hash
+}
+
+// Reads the block node with hash at height from badgerDB
+func getBlockNode(txn *badger.Txn, height uint32, hash lib.BlockHash) (*lib.BlockNode, error) {
+	item, err := txn.Get(blockNodeKey(height, hash))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read block node %v at height %d: %v", hash.String(), height, err)
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return lib.DeserializeBlockNode(val)
+}
+
+// Moves the main chain's tip to the block with tipHash at tipHeight. Returns the
+// _PrefixBlockHashToBlock and _PrefixHeightHashToNodeInfo keys of the blocks whose
+// main chain membership changed, or nil when the chain was empty before.
+func (chain *mainChain) moveTip(txn *badger.Txn, tipHash lib.BlockHash, tipHeight uint64) ([][]byte, error) {
+	oldTipHeight := chain.tipHeight()
+
+	// Walk back from the new tip until reaching a block on the current main chain
+	var path []lib.BlockHash
+	height := int64(tipHeight)
+	hash := tipHash
+	for height >= 0 && !chain.contains(uint64(height), hash) {
+		path = append(path, hash)
+		if height == 0 {
+			height--
+			break
+		}
+		node, err := getBlockNode(txn, uint32(height), hash)
+		if err != nil {
+			return nil, err
+		}
+		if node.Header == nil || node.Header.PrevBlockHash == nil {
+			return nil, fmt.Errorf("Block node %v at height %d has no parent", hash.String(), height)
+		}
+		hash = *node.Header.PrevBlockHash
+		height--
+	}
+	forkHeight := height
+
+	var changedKeys [][]byte
+	// Blocks above the fork point were disconnected
+	for h := forkHeight + 1; h <= oldTipHeight; h++ {
+		changedKeys = append(changedKeys, blockKey(chain.hashes[h]), blockNodeKey(uint32(h), chain.hashes[h]))
+	}
+	chain.hashes = chain.hashes[:forkHeight+1]
+	// Blocks of the new branch were connected
+	for i := len(path) - 1; i >= 0; i-- {
+		if oldTipHeight >= 0 {
+			changedKeys = append(changedKeys, blockKey(path[i]), blockNodeKey(uint32(len(chain.hashes)), path[i]))
+		}
+		chain.hashes = append(chain.hashes, path[i])
+	}
+	if oldTipHeight < 0 {
+		return nil, nil
+	}
+
+	// Block nodes of other forks at heights the tip passed are now orphaned
+	for h := oldTipHeight + 1; h <= int64(tipHeight); h++ {
+		prefix := blockNodeKey(uint32(h), lib.BlockHash{})[:5]
+		itrOptions := badger.DefaultIteratorOptions
+		itrOptions.PrefetchValues = false
+		itrOptions.Prefix = prefix
+		itr := txn.NewIterator(itrOptions)
+		for itr.Seek(prefix); itr.ValidForPrefix(prefix); itr.Next() {
+			var forkHash lib.BlockHash
+			copy(forkHash[:], itr.Item().Key()[5:])
+			if forkHash != chain.hashes[h] {
+				changedKeys = append(changedKeys, blockKey(forkHash), itr.Item().KeyCopy(nil))
+			}
+		}
+		itr.Close()
+	}
+
+	return changedKeys, nil
+}
+
+// Moves the main chain to the best DeSo chain tip in badgerDB and returns the keys
+// of the blocks and block nodes whose InMainChain or Orphaned flags changed
+func (syncSrv *SyncingService) followMainChain(txn *badger.Txn) ([][]byte, error) {
+	tipHashString, tipHeight := getChainTip(txn)
+	if tipHashString == "" {
+		return nil, nil
+	}
+	tipHash, err := parseBlockHash(tipHashString)
+	if err != nil {
+		return nil, err
+	}
+	return syncSrv.mainChain.moveTip(txn, tipHash, tipHeight)
+}
+
+// Builds the main chain as of the checkpoint's tip, so that reorgs that happened
+// while the dumper was down are found when it moves to the current tip
+func (syncSrv *SyncingService) loadMainChain() {
+	if syncSrv.checkpoint == nil || syncSrv.checkpoint.TipBlockHash == "" {
+		return
+	}
+	tipHash, err := parseBlockHash(syncSrv.checkpoint.TipBlockHash)
+	if err != nil {
+		fmt.Printf("Failed to parse checkpoint tip: %v\n", err)
+		return
+	}
+	err = syncSrv.DB.View(func(txn *badger.Txn) error {
+		_, err := syncSrv.mainChain.moveTip(txn, tipHash, syncSrv.checkpoint.TipHeight)
+		return err
+	})
+	if err != nil {
+		fmt.Printf("Failed to load the main chain at the checkpoint tip: %v\n", err)
+		syncSrv.mainChain = &mainChain{}
+	}
+}
+
+// Sets the InMainChain and Orphaned flags of block and block node documents.
+// Block nodes above the tip are neither, since their blocks aren't connected yet.
+func (syncSrv *SyncingService) setChainFlags(key []byte, docMap map[string]interface{}) {
+	if syncSrv.mainChain == nil || syncSrv.mainChain.tipHeight() < 0 {
+		return
+	}
+
+	var hash lib.BlockHash
+	var height uint64
+	switch {
+	case key[0] == 0 && len(key) == 33: // _PrefixBlockHashToBlock
+		header, _ := docMap["Header"].(map[string]interface{})
+		blockHeight, ok := header["Height"].(uint64)
+		if !ok {
+			return
+		}
+		copy(hash[:], key[1:])
+		height = blockHeight
+	case key[0] == 1 && len(key) == 37: // _PrefixHeightHashToNodeInfo
+		copy(hash[:], key[5:])
+		height = uint64(binary.BigEndian.Uint32(key[1:5]))
+	default:
+		return
+	}
+
+	inMainChain := syncSrv.mainChain.contains(height, hash)
+	docMap["InMainChain"] = inMainChain
+	docMap["Orphaned"] = !inMainChain && int64(height) <= syncSrv.mainChain.tipHeight()
+}
+
+// Parses a hex encoded block hash
+func parseBlockHash(hashString string) (lib.BlockHash, error) {
+	var hash lib.BlockHash
+	hashBytes, err := hex.DecodeString(hashString)
+	if err != nil || len(hashBytes) != len(hash) {
+		return hash, fmt.Errorf("Invalid block hash %q", hashString)
+	}
+	copy(hash[:], hashBytes)
+	return hash, nil
+}
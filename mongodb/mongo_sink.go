@@ -0,0 +1,563 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// This file contains the Sink implementation backed by mongoDB
+
+// The _id of the checkpoint document within the metadata collection
+const checkpointDocumentID = "sync_checkpoint"
+
+// Field marking a document whose badgerDB key was deleted when soft deletes are enabled
+const deletedAtField = "DeletedAt"
+
+// Field holding the raw badgerDB key of a document as binary
+const badgerKeyField = "BadgerKey"
+
+// Field older versions stamped every document with the wall-clock time of the write
+const legacyTimeField = "Time"
+
+// Sync metadata fields that keep their stored value when a document is
+// rewritten with unchanged content
+var changeMetadataFields = map[string]bool{
+	badgerVersionField: true,
+	tipHeightField:     true,
+	tipBlockHashField:  true,
+}
+
+// Error code mongoDB returns when a write violates a unique index such as _id
+const duplicateKeyErrorCode = 11000
+
+type MongoSink struct {
+	// SyncDBURI holds a string URI path for connecting to the running mongoDB server
+	SyncDBURI string
+	// mongoDBName holds a string dictating what database within the mongoDB client
+	// to use for storing key/value pairs
+	mongoDBName string
+	// mongoCollectionName holds a string dictating which collection within
+	// the mongoDBName specified database to use for storing key/value pairs
+	mongoCollectionName string
+	// mongoMetadataCollectionName holds a string dictating which collection within
+	// the mongoDBName specified database to use for storing the sync checkpoint
+	mongoMetadataCollectionName string
+	// mongoDeadLetterCollectionName holds a string dictating which collection within
+	// the mongoDBName specified database to use for storing dead letters
+	mongoDeadLetterCollectionName string
+	// prefixCollections maps badgerDB key prefixes to the collection their
+	// documents are written to. Prefixes missing from the map are written to
+	// mongoCollectionName. A nil map writes everything to mongoCollectionName.
+	prefixCollections map[byte]string
+	// softDelete marks documents of deleted badgerDB keys with a DeletedAt
+	// time instead of removing them
+	softDelete bool
+	// historyPrefixes holds the badgerDB key prefixes whose previous document
+	// versions are kept in history collections. A nil map disables history mode.
+	historyPrefixes map[byte]bool
+	// chainTipHeight holds the height of the best chain tip the records being
+	// written were read at, or 0 if it's unknown
+	chainTipHeight uint64
+	// mongoClient is a pointer to the mongo.Client object used for interfacing
+	// with the mongo server dictated by SyncDBURI
+	mongoClient *mongo.Client
+}
+
+// Initializes and returns a new MongoSink with a nil mongo client
+func NewMongoSink(syncDBURI string, mongoDBName string, mongoCollectionName string,
+	mongoMetadataCollectionName string, mongoDeadLetterCollectionName string,
+	prefixCollections map[byte]string, softDelete bool, historyPrefixes map[byte]bool) *MongoSink {
+	return &MongoSink{
+		SyncDBURI:                     syncDBURI,
+		mongoDBName:                   mongoDBName,
+		mongoCollectionName:           mongoCollectionName,
+		mongoMetadataCollectionName:   mongoMetadataCollectionName,
+		mongoDeadLetterCollectionName: mongoDeadLetterCollectionName,
+		prefixCollections:             prefixCollections,
+		softDelete:                    softDelete,
+		historyPrefixes:               historyPrefixes,
+		mongoClient:                   nil,
+	}
+}
+
+// Establishes a MongoDB client with associated URI SyncDBURI
+func (sink *MongoSink) Connect(ctx context.Context) error {
+	// Establish MongoDB client options and create client
+	clientOptions := options.Client().ApplyURI(sink.SyncDBURI)
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return fmt.Errorf("Failed establishing a connection with MongoDB: %v", err)
+	}
+
+	// Check MongoDB Connection and ensure data transmission
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		client.Disconnect(context.Background())
+		return fmt.Errorf("Failed to ping MongoDB: %v", err)
+	}
+
+	fmt.Println("Successfully Connected to MongoDB.")
+	sink.mongoClient = client
+	if len(sink.historyPrefixes) != 0 {
+		sink.createHistoryIndexes()
+	}
+	return nil
+}
+
+// Disconnects from MongoDB Client client
+func (sink *MongoSink) Close() error {
+	if sink.mongoClient == nil {
+		return nil
+	}
+	return sink.mongoClient.Disconnect(context.Background())
+}
+
+// Returns the _id of the document for key, which is the hex encoded key
+func documentID(key []byte) string {
+	return hex.EncodeToString(key)
+}
+
+// Returns the name of the collection holding the document for key
+func (sink *MongoSink) collectionName(key []byte) string {
+	if len(key) != 0 {
+		if name, exists := sink.prefixCollections[key[0]]; exists {
+			return name
+		}
+	}
+	return sink.mongoCollectionName
+}
+
+// Returns the names of all collections holding synced key/value pairs
+func (sink *MongoSink) collectionNames() []string {
+	names := []string{sink.mongoCollectionName}
+	seen := map[string]bool{sink.mongoCollectionName: true}
+	for _, name := range sink.prefixCollections {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Returns the collection holding sync metadata such as the checkpoint
+func (sink *MongoSink) metadataCollection() *mongo.Collection {
+	return sink.mongoClient.Database(sink.mongoDBName).Collection(sink.mongoMetadataCollectionName)
+}
+
+// Executes an unordered bulk write of the ops grouped by collection name.
+// keysByCollection holds the badgerDB key of each op, which is used to
+// report the failed records in a BatchWriteError of MongoWriteErrors.
+func (sink *MongoSink) executeBulkWrite(opsByCollection map[string][]mongo.WriteModel,
+	keysByCollection map[string][][]byte) error {
+	bulkOption := options.BulkWriteOptions{}
+	bulkOption.SetOrdered(false) // Continues writes even if an error occurs
+
+	failedKeys := make(map[string]error)
+	for name, ops := range opsByCollection {
+		collection := sink.mongoClient.Database(sink.mongoDBName).Collection(name)
+		result, err := collection.BulkWrite(context.Background(), ops, &bulkOption)
+		if err == nil {
+			logBulkWrite(name, len(ops), result, nil)
+			continue
+		}
+
+		collectionFailures := bulkWriteFailures(name, keysByCollection[name], err)
+		logBulkWrite(name, len(ops), result, collectionFailures)
+		for key, keyErr := range collectionFailures {
+			failedKeys[key] = keyErr
+		}
+	}
+	if len(failedKeys) != 0 {
+		return &BatchWriteError{FailedKeys: failedKeys}
+	}
+	return nil
+}
+
+// Upserts every record into the document identified by its key. In history
+// mode the previous version of a changed document is archived first.
+func (sink *MongoSink) UpsertBatch(records []*Record) error {
+	var validFromHeights map[string]uint64
+	var failedKeys map[string]error
+	if len(sink.historyPrefixes) != 0 {
+		validFromHeights, failedKeys = sink.archiveChangedVersions(records)
+	}
+
+	opsByCollection := make(map[string][]mongo.WriteModel)
+	keysByCollection := make(map[string][][]byte)
+	for _, record := range records {
+		if _, failed := failedKeys[string(record.Key)]; failed {
+			// Writing the record would lose its previous version
+			continue
+		}
+
+		doc := make(bson.M, len(record.Doc)+2)
+		for field, val := range record.Doc {
+			doc[field] = val
+		}
+		doc[badgerKeyField] = record.Key
+		if height, ok := validFromHeights[string(record.Key)]; ok {
+			doc[validFromHeightField] = int64(height)
+		}
+
+		op := mongo.NewUpdateOneModel()
+		op.SetFilter(bson.M{"_id": documentID(record.Key)})
+		op.SetUpdate(sink.upsertPipeline(record, doc))
+		op.SetUpsert(true)
+		name := sink.collectionName(record.Key)
+		opsByCollection[name] = append(opsByCollection[name], op)
+		keysByCollection[name] = append(keysByCollection[name], record.Key)
+	}
+
+	return mergeBatchWriteErrors(failedKeys, sink.executeBulkWrite(opsByCollection, keysByCollection))
+}
+
+// Returns the update pipeline writing doc, the document of record. A document
+// whose stored content hash matches keeps its LastChanged time and the sync
+// metadata of its last change, so that rewriting unchanged content doesn't
+// modify it. FirstSeen is only set if the document doesn't have it yet.
+func (sink *MongoSink) upsertPipeline(record *Record, doc bson.M) bson.A {
+	now := time.Now()
+	var changed interface{} = true
+	set := bson.M{}
+	if hash, err := contentHash(record.Doc); err == nil {
+		hashHex := hex.EncodeToString(hash)
+		changed = bson.M{"$ne": bson.A{"$" + contentHashField, hashHex}}
+		set[contentHashField] = hashHex
+	}
+	if sink.softDelete {
+		// A document that reappears after being deleted changed as well
+		changed = bson.M{"$or": bson.A{changed,
+			bson.M{"$ne": bson.A{bson.M{"$type": "$" + deletedAtField}, "missing"}}}}
+	}
+
+	// Values are wrapped in $literal since pipeline stages read strings
+	// starting with $ as field paths
+	for field, val := range doc {
+		if changeMetadataFields[field] {
+			set[field] = bson.M{"$cond": bson.A{changed, bson.M{"$literal": val}, "$" + field}}
+			continue
+		}
+		set[field] = bson.M{"$literal": val}
+	}
+	set[lastChangedField] = bson.M{"$cond": bson.A{changed, bson.M{"$literal": now}, "$" + lastChangedField}}
+	set[firstSeenField] = bson.M{"$ifNull": bson.A{"$" + firstSeenField, bson.M{"$literal": now}}}
+
+	unset := bson.A{legacyTimeField}
+	if sink.softDelete {
+		// A key that reappears in badgerDB is no longer deleted
+		unset = append(unset, deletedAtField)
+	}
+	return bson.A{bson.M{"$set": set}, bson.M{"$unset": unset}}
+}
+
+// Removes the documents identified by keys, or marks them with a DeletedAt
+// time if soft deletes are enabled. In history mode the removed version is
+// archived first.
+func (sink *MongoSink) DeleteBatch(keys [][]byte) error {
+	var failedKeys map[string]error
+	if len(sink.historyPrefixes) != 0 {
+		failedKeys = sink.archiveDeletedVersions(keys)
+	}
+
+	opsByCollection := make(map[string][]mongo.WriteModel)
+	keysByCollection := make(map[string][][]byte)
+	for _, key := range keys {
+		if _, failed := failedKeys[string(key)]; failed {
+			continue
+		}
+		name := sink.collectionName(key)
+		keysByCollection[name] = append(keysByCollection[name], key)
+		if sink.softDelete {
+			op := mongo.NewUpdateOneModel()
+			op.SetFilter(bson.M{"_id": documentID(key), deletedAtField: bson.M{"$exists": false}})
+			op.SetUpdate(bson.M{"$set": bson.M{deletedAtField: time.Now()}})
+			opsByCollection[name] = append(opsByCollection[name], op)
+			continue
+		}
+
+		op := mongo.NewDeleteOneModel()
+		op.SetFilter(bson.M{"_id": documentID(key)})
+		opsByCollection[name] = append(opsByCollection[name], op)
+	}
+
+	return mergeBatchWriteErrors(failedKeys, sink.executeBulkWrite(opsByCollection, keysByCollection))
+}
+
+// Adds failedKeys to the BatchWriteError err, returning err if failedKeys is empty
+func mergeBatchWriteErrors(failedKeys map[string]error, err error) error {
+	if len(failedKeys) == 0 {
+		return err
+	}
+	if batchErr, ok := err.(*BatchWriteError); ok {
+		for key, keyErr := range batchErr.FailedKeys {
+			failedKeys[key] = keyErr
+		}
+	}
+	return &BatchWriteError{FailedKeys: failedKeys}
+}
+
+// Calls fn with the key of every document that isn't soft deleted
+func (sink *MongoSink) ForEachKey(fn func(key []byte) error) error {
+	for _, name := range sink.collectionNames() {
+		if err := sink.forEachKeyInCollection(name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Calls fn with the key of every document in the named collection that isn't soft deleted
+func (sink *MongoSink) forEachKeyInCollection(name string, fn func(key []byte) error) error {
+	filter := bson.M{}
+	if sink.softDelete {
+		filter[deletedAtField] = bson.M{"$exists": false}
+	}
+	collection := sink.mongoClient.Database(sink.mongoDBName).Collection(name)
+	cursor, err := collection.Find(context.Background(), filter,
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var doc struct {
+			ID string `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			continue
+		}
+		key, err := hex.DecodeString(doc.ID)
+		if err != nil {
+			// Documents with raw key _ids are left for MigrateDocumentIDs
+			continue
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// Reads the sync checkpoint from the metadata collection
+func (sink *MongoSink) GetCheckpoint() (*SyncCheckpoint, error) {
+	checkpoint := &SyncCheckpoint{}
+	err := sink.metadataCollection().FindOne(
+		context.Background(), bson.M{"_id": checkpointDocumentID}).Decode(checkpoint)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// Writes checkpoint to the metadata collection, replacing any previous checkpoint
+func (sink *MongoSink) SaveCheckpoint(checkpoint *SyncCheckpoint) error {
+	_, err := sink.metadataCollection().ReplaceOne(
+		context.Background(), bson.M{"_id": checkpointDocumentID}, checkpoint,
+		options.Replace().SetUpsert(true))
+	return err
+}
+
+// Removes the sync checkpoint from the metadata collection
+func (sink *MongoSink) DeleteCheckpoint() error {
+	_, err := sink.metadataCollection().DeleteOne(
+		context.Background(), bson.M{"_id": checkpointDocumentID})
+	return err
+}
+
+// Rewrites documents written by older versions, whose _id is the raw badgerDB
+// key cast to a string, to use the hex encoded key as _id and store the raw key
+// in the BadgerKey field. Documents are moved to the collection their prefix
+// maps to, so this also migrates the single collection layout to the per-prefix
+// layout. Returns the number of documents migrated.
+func (sink *MongoSink) MigrateDocumentIDs() (int, error) {
+	totalMigrated := 0
+	for _, name := range sink.collectionNames() {
+		migrated, err := sink.migrateDocumentIDsInCollection(name)
+		totalMigrated += migrated
+		if err != nil {
+			return totalMigrated, err
+		}
+	}
+	return totalMigrated, nil
+}
+
+// documentMigration is a document to be moved from its legacy _id to its new _id
+type documentMigration struct {
+	oldID string
+	// targetName holds the collection the document is inserted into
+	targetName string
+	doc        bson.M
+}
+
+// Rewrites the documents without a BadgerKey field in the named collection
+func (sink *MongoSink) migrateDocumentIDsInCollection(name string) (int, error) {
+	collection := sink.mongoClient.Database(sink.mongoDBName).Collection(name)
+	cursor, err := collection.Find(context.Background(), bson.M{badgerKeyField: bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	totalMigrated := 0
+	var pending []*documentMigration
+	for cursor.Next(context.Background()) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			continue
+		}
+		rawID, ok := doc["_id"].(string)
+		if !ok {
+			continue
+		}
+		key := []byte(rawID)
+
+		doc["_id"] = documentID(key)
+		doc[badgerKeyField] = key
+		pending = append(pending, &documentMigration{oldID: rawID, targetName: sink.collectionName(key), doc: doc})
+
+		if len(pending) >= bulkWriteChunkSize {
+			migrated, err := sink.executeMigrationWrite(name, pending)
+			totalMigrated += migrated
+			if err != nil {
+				return totalMigrated, err
+			}
+			pending = nil
+		}
+	}
+	if len(pending) != 0 {
+		migrated, err := sink.executeMigrationWrite(name, pending)
+		totalMigrated += migrated
+		if err != nil {
+			return totalMigrated, err
+		}
+	}
+
+	return totalMigrated, cursor.Err()
+}
+
+// Inserts the migrated documents under their new _id and afterwards removes the
+// documents under the old _id from the named collection, only for the inserts
+// that succeeded. A document that already exists under the new _id was written
+// by a newer version and is kept, so its duplicate key error counts as success.
+// Documents that failed to be inserted are logged and keep their old _id.
+// Returns the number of migrated documents.
+func (sink *MongoSink) executeMigrationWrite(name string, migrations []*documentMigration) (int, error) {
+	bulkOption := options.BulkWriteOptions{}
+	bulkOption.SetOrdered(false) // Continues writes even if an error occurs
+
+	migrationsByTarget := make(map[string][]*documentMigration)
+	for _, migration := range migrations {
+		migrationsByTarget[migration.targetName] = append(migrationsByTarget[migration.targetName], migration)
+	}
+
+	var deleteIDs []string
+	for targetName, targetMigrations := range migrationsByTarget {
+		ops := make([]mongo.WriteModel, len(targetMigrations))
+		for i, migration := range targetMigrations {
+			ops[i] = mongo.NewInsertOneModel().SetDocument(migration.doc)
+		}
+
+		collection := sink.mongoClient.Database(sink.mongoDBName).Collection(targetName)
+		_, err := collection.BulkWrite(context.Background(), ops, &bulkOption)
+		failed := make(map[int]bool)
+		if err != nil {
+			bulkErr, ok := err.(mongo.BulkWriteException)
+			if !ok || bulkErr.WriteConcernError != nil {
+				// It's unknown which documents were inserted, so none are removed
+				return 0, err
+			}
+			for _, writeErr := range bulkErr.WriteErrors {
+				if writeErr.Code == duplicateKeyErrorCode {
+					continue
+				}
+				failed[writeErr.Index] = true
+				if writeErr.Index >= 0 && writeErr.Index < len(targetMigrations) {
+					fmt.Printf("Failed to migrate document %v in %v, keeping it: MongoDB write error %d: %v\n",
+						targetMigrations[writeErr.Index].oldID, name, writeErr.Code, writeErr.Message)
+				}
+			}
+		}
+
+		for i, migration := range targetMigrations {
+			if !failed[i] {
+				deleteIDs = append(deleteIDs, migration.oldID)
+			}
+		}
+	}
+	if len(deleteIDs) == 0 {
+		return 0, nil
+	}
+
+	collection := sink.mongoClient.Database(sink.mongoDBName).Collection(name)
+	_, err := collection.DeleteMany(context.Background(), bson.M{"_id": bson.M{"$in": deleteIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return len(deleteIDs), nil
+}
+
+// Returns the collection holding dead letters
+func (sink *MongoSink) deadLetterCollection() *mongo.Collection {
+	return sink.mongoClient.Database(sink.mongoDBName).Collection(sink.mongoDeadLetterCollectionName)
+}
+
+// Writes letters to the dead-letter collection, replacing any letter with the same key
+func (sink *MongoSink) AddDeadLetters(letters []*DeadLetter) error {
+	if len(letters) == 0 {
+		return nil
+	}
+	var ops []mongo.WriteModel
+	for _, letter := range letters {
+		op := mongo.NewReplaceOneModel()
+		op.SetFilter(bson.M{"_id": letter.Key})
+		op.SetReplacement(letter)
+		op.SetUpsert(true)
+		ops = append(ops, op)
+	}
+
+	bulkOption := options.BulkWriteOptions{}
+	bulkOption.SetOrdered(false)
+	_, err := sink.deadLetterCollection().BulkWrite(context.Background(), ops, &bulkOption)
+	return err
+}
+
+// Calls fn with every letter in the dead-letter collection
+func (sink *MongoSink) ForEachDeadLetter(fn func(letter *DeadLetter) error) error {
+	cursor, err := sink.deadLetterCollection().Find(context.Background(), bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		letter := &DeadLetter{}
+		if err := cursor.Decode(letter); err != nil {
+			return err
+		}
+		if err := fn(letter); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Removes the letters of the hex encoded keys from the dead-letter collection
+func (sink *MongoSink) RemoveDeadLetters(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := sink.deadLetterCollection().DeleteMany(context.Background(), bson.M{"_id": bson.M{"$in": keys}})
+	return err
+}
@@ -0,0 +1,207 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/dgraph-io/badger/v3/pb"
+	"github.com/dgraph-io/ristretto/z"
+)
+
+// This file contains the parallel full scan of badgerDB. Each key prefix is read
+// with badger's Stream framework, decoded by a pool of workers and queued for the
+// sink's writers by a single goroutine, so that reading, decoding and the sink's
+// round trips overlap.
+
+// scannedKV is a badgerDB key/value pair read by a parallel scan
+type scannedKV struct {
+	key     []byte
+	val     []byte
+	version uint64
+}
+
+// decodedKV is a scannedKV and the record decoded from it
+type decodedKV struct {
+	scannedKV
+	record *Record
+	err    error
+}
+
+// Scans badgerDB like scan, streaming one key prefix at a time. Keys of a prefix
+// are queued out of order, so the checkpoint is only advanced once every key of
+// a prefix was written. Records are stamped with the chain tip at the start of
+// the scan, although streams read the latest values.
+func (syncSrv *SyncingService) parallelScan(ctx context.Context, startKey []byte, sinceVersion uint64,
+	checkpoint *SyncCheckpoint) (*ScanSummary, error) {
+	summary := &ScanSummary{PrefixStats: NewPrefixStats()}
+	startTime := time.Now()
+
+	var scanStamp recordStamp
+	var prefixes []byte
+	err := syncSrv.DB.View(func(txn *badger.Txn) error {
+		scanStamp = syncSrv.startScan(txn, summary, checkpoint)
+		prefixes = keyPrefixes(txn, startKey)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batch := syncSrv.newBatchWriter(ctx)
+	if checkpoint != nil {
+		batch.onProgress = func(key []byte) {
+			checkpoint.LastSyncedKey = hex.EncodeToString(key)
+			syncSrv.saveCheckpoint(checkpoint)
+		}
+	}
+	for _, prefix := range prefixes {
+		var prefixStartKey []byte
+		if len(startKey) != 0 && startKey[0] == prefix {
+			prefixStartKey = startKey
+		}
+		lastKey, err := syncSrv.scanPrefix(ctx, prefix, prefixStartKey, sinceVersion, scanStamp, batch, summary)
+		if err != nil {
+			batch.close()
+			return nil, err
+		}
+
+		// Every key of the prefix was queued, so once the queued writes are
+		// done the scan can resume after the prefix
+		if lastKey != nil {
+			batch.progress(lastKey)
+		}
+	}
+	// Wait for the remaining bulk operations
+	batch.close()
+	summary.RecordsUnchanged = batch.unchanged
+	summary.UnsyncedKeys = batch.unsynced
+
+	summary.Duration = time.Since(startTime)
+	return summary, nil
+}
+
+// Returns the prefixes of the badgerDB keys from startKey on in ascending order
+func keyPrefixes(txn *badger.Txn, startKey []byte) []byte {
+	itrOptions := badger.DefaultIteratorOptions
+	itrOptions.PrefetchValues = false
+	itr := txn.NewIterator(itrOptions)
+	defer itr.Close()
+
+	var prefixes []byte
+	for itr.Seek(startKey); itr.Valid(); {
+		key := itr.Item().Key()
+		if len(key) == 0 {
+			itr.Next()
+			continue
+		}
+		prefixes = append(prefixes, key[0])
+		if key[0] == math.MaxUint8 {
+			break
+		}
+		itr.Seek([]byte{key[0] + 1})
+	}
+	return prefixes
+}
+
+// Streams the keys with prefix after startKey whose version is above sinceVersion
+// through the decode workers into batch. Returns the greatest key read, or
+// ctx.Err() if ctx was cancelled before every key was read.
+func (syncSrv *SyncingService) scanPrefix(ctx context.Context, prefix byte, startKey []byte, sinceVersion uint64,
+	scanStamp recordStamp, batch *batchWriter, summary *ScanSummary) ([]byte, error) {
+	scanned := make(chan *scannedKV, batch.batchSize)
+	decoded := make(chan *decodedKV, batch.batchSize)
+
+	// Decode the scanned keys in parallel
+	var workers sync.WaitGroup
+	for i := 0; i < syncSrv.scanWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for kv := range scanned {
+				stamp := scanStamp
+				stamp.BadgerVersion = kv.version
+				record, err := syncSrv.newRecord(kv.key, kv.val, &stamp)
+				decoded <- &decodedKV{scannedKV: *kv, record: record, err: err}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(decoded)
+	}()
+
+	// Queue the decoded records for the sink from a single goroutine
+	var lastKey []byte
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for kv := range decoded {
+			summary.KeysScanned++
+			summary.PrefixStats.record(kv.key, kv.err)
+			if bytes.Compare(kv.key, lastKey) > 0 {
+				lastKey = kv.key
+			}
+			if kv.err != nil {
+				if kv.err != ErrUnknownPrefix {
+					batch.deadLetter(newDeadLetter(kv.key, kv.val, DeadLetterStageDecode, kv.err))
+				}
+				continue
+			}
+			summary.RecordsUpserted++
+			batch.upsert(kv.record)
+		}
+	}()
+
+	var readFailures int64
+	stream := syncSrv.DB.NewStream()
+	stream.Prefix = []byte{prefix}
+	stream.SinceTs = sinceVersion
+	stream.LogPrefix = fmt.Sprintf("Scan of prefix %d", prefix)
+	if startKey != nil {
+		// The start key itself was synced before the scan was interrupted
+		stream.ChooseKey = func(item *badger.Item) bool {
+			return bytes.Compare(item.Key(), startKey) > 0
+		}
+	}
+	stream.KeyToList = func(key []byte, itr *badger.Iterator) (*pb.KVList, error) {
+		// Only the latest version of a key is synced
+		item := itr.Item()
+		if item.IsDeletedOrExpired() {
+			return nil, nil
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			atomic.AddInt64(&readFailures, 1)
+			summary.PrefixStats.record(key, err)
+			return nil, nil
+		}
+		return &pb.KVList{Kv: []*pb.KV{{Key: key, Value: val, Version: item.Version()}}}, nil
+	}
+	stream.Send = func(buf *z.Buffer) error {
+		list, err := badger.BufferToKVList(buf)
+		if err != nil {
+			return err
+		}
+		for _, kv := range list.Kv {
+			select {
+			case scanned <- &scannedKV{key: kv.Key, val: kv.Value, version: kv.Version}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+
+	err := stream.Orchestrate(ctx)
+	close(scanned)
+	<-writerDone
+	summary.KeysScanned += int(readFailures)
+	return lastKey, err
+}
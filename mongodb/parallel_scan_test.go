@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// Number of keys in the badgerDB the scan benchmarks read
+const benchmarkKeys = 100000
+
+// discardSink is a Sink that drops every write, so that benchmarks only
+// measure reading and decoding
+type discardSink struct{}
+
+func (discardSink) Connect(ctx context.Context) error               { return nil }
+func (discardSink) UpsertBatch(records []*Record) error             { return nil }
+func (discardSink) DeleteBatch(keys [][]byte) error                 { return nil }
+func (discardSink) ForEachKey(fn func(key []byte) error) error      { return nil }
+func (discardSink) GetCheckpoint() (*SyncCheckpoint, error)         { return nil, nil }
+func (discardSink) SaveCheckpoint(checkpoint *SyncCheckpoint) error { return nil }
+func (discardSink) DeleteCheckpoint() error                         { return nil }
+func (discardSink) Close() error                                    { return nil }
+
+// Opens a temporary badgerDB holding numKeys follow and like keys, which
+// are spread over four prefixes and decode without a value
+func newBenchmarkDB(b *testing.B, numKeys int) *badger.DB {
+	db, err := badger.Open(badger.DefaultOptions(b.TempDir()).WithLogger(nil))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	batch := db.NewWriteBatch()
+	defer batch.Cancel()
+	for i := 0; i < numKeys; i++ {
+		// <prefix, public key or PKID [33]byte, public key, PKID or post hash [33]byte>
+		key := make([]byte, 67)
+		key[0] = byte(28 + i%4)
+		binary.BigEndian.PutUint64(key[1:], uint64(i))
+		binary.BigEndian.PutUint64(key[34:], uint64(i))
+		if err := batch.Set(key, []byte{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := batch.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+// Compares the serial scan loop with parallel scans of several worker counts
+func BenchmarkScan(b *testing.B) {
+	db := newBenchmarkDB(b, benchmarkKeys)
+	defer db.Close()
+
+	workerCounts := []int{1, 2, 4}
+	if runtime.NumCPU() > 4 {
+		workerCounts = append(workerCounts, runtime.NumCPU())
+	}
+	for _, scanWorkers := range workerCounts {
+		b.Run(fmt.Sprintf("workers=%d", scanWorkers), func(b *testing.B) {
+			syncSrv := NewSyncingService(db, discardSink{}, SyncModeFull, false, RecordEncodingBSON,
+				nil, nil, scanWorkers, bulkWriteChunkSize, 4, DefaultRetryPolicy)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				summary, err := syncSrv.scan(context.Background(), nil, 0, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if summary.RecordsUpserted != benchmarkKeys {
+					b.Fatalf("Decoded %d of %d keys", summary.RecordsUpserted, benchmarkKeys)
+				}
+				b.ReportMetric(summary.Throughput(), "keys/s")
+			}
+		})
+	}
+}
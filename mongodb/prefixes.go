@@ -0,0 +1,33 @@
+package mongodb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// This file contains the naming and reporting of badgerDB key prefixes
+
+// Holds the unknown prefixes already reported, so each is only logged once
+var reportedUnknownPrefixes = make(map[byte]struct{})
+var reportedUnknownPrefixesLock sync.Mutex
+
+// Returns the name of prefix, or "unknown" if it has no registered decoder
+func PrefixName(prefix byte) string {
+	if decoder := GetDecoder(prefix); decoder != nil {
+		return decoder.Name()
+	}
+	return "unknown"
+}
+
+// Logs that keys with prefix are skipped because the dumper can't decode them.
+// Each prefix is only reported the first time it's seen.
+func reportUnknownPrefix(prefix byte) {
+	reportedUnknownPrefixesLock.Lock()
+	defer reportedUnknownPrefixesLock.Unlock()
+
+	if _, reported := reportedUnknownPrefixes[prefix]; reported {
+		return
+	}
+	reportedUnknownPrefixes[prefix] = struct{}{}
+	fmt.Printf("Skipping keys with unknown prefix %d, the dumper may need to be updated to the core version of the node.\n", prefix)
+}
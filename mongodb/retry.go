@@ -0,0 +1,189 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// This file contains the retries of failed sink operations and the health state
+// of the SyncingService they feed
+
+// RetryPolicy dictates how failed sink connections and bulk writes are retried
+type RetryPolicy struct {
+	// WriteAttempts is the number of times a bulk write is attempted before the
+	// records that failed every attempt are given up on. Connecting is retried
+	// until it succeeds.
+	WriteAttempts int
+	// InitialBackoff is the wait before the first retry, which doubles with
+	// every further retry up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy is used by SyncingServices created without a RetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	WriteAttempts:  5,
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+}
+
+// Returns the wait before retry number retry, starting at 1
+func (policy RetryPolicy) backoff(retry int) time.Duration {
+	wait := policy.InitialBackoff
+	for i := 1; i < retry && wait < policy.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > policy.MaxBackoff {
+		wait = policy.MaxBackoff
+	}
+	return wait
+}
+
+// Waits for wait to pass. Returns false if ctx was cancelled first.
+func sleepContext(ctx context.Context, wait time.Duration) bool {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// SyncHealth describes whether the SyncingService keeps the sink up to date
+type SyncHealth struct {
+	// Degraded is true while the sink can't be connected to or its writes keep failing
+	Degraded bool `bson:"Degraded" json:"Degraded"`
+	// Reason holds the error that degraded the sync
+	Reason string `bson:"Reason,omitempty" json:"Reason,omitempty"`
+	// Since holds when the sync was last degraded or recovered
+	Since time.Time `bson:"Since" json:"Since"`
+}
+
+// healthTracker holds the SyncHealth of a SyncingService, which its writers update concurrently
+type healthTracker struct {
+	lock   sync.Mutex
+	health SyncHealth
+}
+
+// Returns the current SyncHealth
+func (tracker *healthTracker) get() SyncHealth {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	return tracker.health
+}
+
+// Returns whether the SyncingService keeps the sink up to date, and since when
+func (syncSrv *SyncingService) Health() SyncHealth {
+	return syncSrv.health.get()
+}
+
+// Marks the sync as degraded by reason, logging the change
+func (tracker *healthTracker) degrade(reason string) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	if !tracker.health.Degraded {
+		fmt.Printf("Sync degraded: %v\n", reason)
+		tracker.health.Since = time.Now()
+	}
+	tracker.health.Degraded = true
+	tracker.health.Reason = reason
+}
+
+// Marks the sync as healthy, logging the change
+func (tracker *healthTracker) recover() {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	if !tracker.health.Degraded {
+		return
+	}
+	fmt.Printf("Sync recovered after being degraded for %v.\n", time.Since(tracker.health.Since))
+	tracker.health = SyncHealth{Since: time.Now()}
+}
+
+// Connects the sink, retrying with exponential backoff until it succeeds. The
+// sync is degraded while it fails. Returns ctx.Err() if ctx is cancelled first.
+func (syncSrv *SyncingService) Connect(ctx context.Context) error {
+	for retry := 1; ; retry++ {
+		err := syncSrv.Sink.Connect(ctx)
+		if err == nil {
+			syncSrv.health.recover()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		syncSrv.health.degrade(fmt.Sprintf("Failed to connect to sink: %v", err))
+
+		wait := syncSrv.retry.backoff(retry)
+		fmt.Printf("Retrying to connect to sink in %v.\n", wait)
+		if !sleepContext(ctx, wait) {
+			return ctx.Err()
+		}
+	}
+}
+
+// Calls write with keys, and then with the keys that failed with an error worth
+// retrying, until every key was written or the policy's attempts are used up.
+// Returns a BatchWriteError holding the keys that failed for good, or nil.
+func (bw *batchWriter) writeWithRetry(action string, keys [][]byte, write func(keys [][]byte) error) error {
+	attempts := bw.retry.WriteAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	failedKeys := make(map[string]error)
+	for attempt := 1; ; attempt++ {
+		err := write(keys)
+		var retried [][]byte
+		networkFailures := 0
+		for _, key := range keys {
+			keyErr := batchErrorForKey(err, key)
+			if keyErr == nil {
+				delete(failedKeys, string(key))
+				continue
+			}
+			failedKeys[string(key)] = keyErr
+			switch writeErrorClass(keyErr) {
+			case WriteErrorValidation:
+				// The document is rejected again on every attempt
+				continue
+			case WriteErrorNetwork:
+				networkFailures++
+			}
+			retried = append(retried, key)
+		}
+		if networkFailures != 0 {
+			bw.health.degrade(fmt.Sprintf("Failed to %v %d records: %v", action, networkFailures, err))
+		} else {
+			// The sink answered, even if it rejected some records
+			bw.health.recover()
+		}
+
+		if len(failedKeys) == 0 {
+			return nil
+		}
+		batchErr := &BatchWriteError{FailedKeys: failedKeys}
+		if len(retried) == 0 || attempt >= attempts {
+			if len(retried) != 0 {
+				fmt.Printf("Giving up on %d records after %d attempts (%v).\n", len(failedKeys),
+					attempts, countWriteErrorClasses(failedKeys))
+			}
+			return batchErr
+		}
+		wait := bw.retry.backoff(attempt)
+		fmt.Printf("Failed to %v %d records (%v) on attempt %d of %d, retrying %d in %v.\n",
+			action, len(failedKeys), countWriteErrorClasses(failedKeys), attempt, attempts, len(retried), wait)
+		if !sleepContext(bw.ctx, wait) {
+			// Shutting down, so the failed records are handled like after the last attempt
+			return batchErr
+		}
+		keys = retried
+	}
+}
@@ -0,0 +1,90 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{WriteAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{100, 5 * time.Second},
+	}
+	for _, test := range tests {
+		if got := policy.backoff(test.retry); got != test.want {
+			t.Errorf("backoff(%d) = %v, want %v", test.retry, got, test.want)
+		}
+	}
+
+	// The initial backoff is capped too
+	policy = RetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Second}
+	if got := policy.backoff(1); got != time.Second {
+		t.Errorf("backoff(1) = %v, want %v", got, time.Second)
+	}
+}
+
+func TestWriteWithRetry(t *testing.T) {
+	retry := RetryPolicy{WriteAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	bw := newBatchWriter(context.Background(), discardSink{}, nil, nil, 1, 1, retry, &healthTracker{})
+	defer bw.close()
+
+	// b fails with a network error every time, c is rejected as invalid
+	attempts := make(map[string]int)
+	err := bw.writeWithRetry("write", [][]byte{[]byte("a"), []byte("b"), []byte("c")}, func(keys [][]byte) error {
+		failedKeys := make(map[string]error)
+		for _, key := range keys {
+			attempts[string(key)]++
+			writeErr := newMongoWriteError("test", key, -1, "failed")
+			switch string(key) {
+			case "b":
+				writeErr.Class = WriteErrorNetwork
+			case "c":
+				writeErr.Class = WriteErrorValidation
+			default:
+				continue
+			}
+			failedKeys[string(key)] = writeErr
+		}
+		return &BatchWriteError{FailedKeys: failedKeys}
+	})
+
+	if batchErrorForKey(err, []byte("a")) != nil {
+		t.Errorf("Key a failed: %v", err)
+	}
+	if batchErrorForKey(err, []byte("b")) == nil || batchErrorForKey(err, []byte("c")) == nil {
+		t.Errorf("Keys b and c didn't fail: %v", err)
+	}
+	want := map[string]int{"a": 1, "b": retry.WriteAttempts, "c": 1}
+	for key, count := range want {
+		if attempts[key] != count {
+			t.Errorf("Attempted to write %v %d times, want %d", key, attempts[key], count)
+		}
+	}
+	if !bw.health.get().Degraded {
+		t.Error("Network errors didn't degrade the sync")
+	}
+}
+
+func TestCheckpointHoldsHealth(t *testing.T) {
+	sink := NewJSONSink("-")
+	syncSrv := NewSyncingService(nil, sink, SyncModeFull, false, RecordEncodingBSON,
+		nil, nil, 1, bulkWriteChunkSize, 1, DefaultRetryPolicy)
+	syncSrv.health.degrade("connection refused")
+	syncSrv.saveCheckpoint(&SyncCheckpoint{})
+
+	checkpoint, err := sink.GetCheckpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Health == nil || !checkpoint.Health.Degraded || checkpoint.Health.Reason != "connection refused" {
+		t.Errorf("Got checkpoint health %+v, want the degraded health", checkpoint.Health)
+	}
+}
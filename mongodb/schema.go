@@ -0,0 +1,99 @@
+package mongodb
+
+import (
+	"reflect"
+	"time"
+)
+
+// JSONSchemaDraft is the JSON Schema version GenerateJSONSchema produces
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// Generates the JSON Schema of a typed document such as BlockDoc. Fields are
+// named by their bson tags, pointer fields may be null and byte slices are
+// described as the base64 strings they are encoded as in JSON, with the
+// bsonType of the binary they are stored as in mongoDB.
+func GenerateJSONSchema(doc interface{}) map[string]interface{} {
+	docType := reflect.TypeOf(doc)
+	for docType.Kind() == reflect.Ptr {
+		docType = docType.Elem()
+	}
+
+	schema := typeToJSONSchema(docType)
+	schema["$schema"] = JSONSchemaDraft
+	schema["title"] = docType.Name()
+	return schema
+}
+
+// Returns the JSON Schema of a Go type
+func typeToJSONSchema(typ reflect.Type) map[string]interface{} {
+	switch typ.Kind() {
+	case reflect.Ptr:
+		schema := typeToJSONSchema(typ.Elem())
+		if schemaType, ok := schema["type"].(string); ok {
+			schema["type"] = []string{schemaType, "null"}
+		}
+		return schema
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64", "bsonType": "binData"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeToJSONSchema(typ.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeToJSONSchema(typ.Elem())}
+	case reflect.Struct:
+		if typ == reflect.TypeOf(time.Time{}) {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		properties := make(map[string]interface{})
+		required := []string{}
+		addStructProperties(typ, properties, &required)
+		// Additional properties are allowed, since sinks add fields such as _id
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	}
+	// Interfaces may hold any value
+	return map[string]interface{}{}
+}
+
+// Adds the JSON Schema of the exported fields of a struct type to properties
+func addStructProperties(typ reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, inline, omitEmpty := parseBSONTag(field)
+		switch {
+		case name == "-":
+			continue
+		case inline:
+			addStructProperties(field.Type, properties, required)
+			continue
+		}
+
+		schema := typeToJSONSchema(field.Type)
+		if field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Map {
+			// Empty slices and maps are written as null
+			if schemaType, ok := schema["type"].(string); ok {
+				schema["type"] = []string{schemaType, "null"}
+			}
+		}
+		properties[name] = schema
+		if !omitEmpty {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,379 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// This file contains the Sink interface the SyncingService writes badgerDB records to
+
+// SinkType names a Sink implementation selectable from the command line
+type SinkType string
+
+const (
+	// SinkTypeMongo writes records to a mongoDB collection
+	SinkTypeMongo SinkType = "mongo"
+	// SinkTypeJSON writes records as newline delimited JSON, mostly for debugging
+	SinkTypeJSON SinkType = "json"
+)
+
+// Record is a decoded badgerDB key/value pair ready to be written to a Sink
+type Record struct {
+	// Key holds the raw badgerDB key the record was decoded from
+	Key []byte
+	// Value holds the raw badgerDB value the record was decoded from
+	Value []byte
+	// Doc holds the decoded document
+	Doc map[string]interface{}
+}
+
+// Sink is a store the SyncingService writes decoded badgerDB records to.
+// UpsertBatch, DeleteBatch and SaveCheckpoint may be called concurrently.
+type Sink interface {
+	// Connect establishes the connection to the store, giving up once ctx
+	// is cancelled. It is called once before any other method.
+	Connect(ctx context.Context) error
+	// UpsertBatch writes records, replacing any stored record with the same key
+	UpsertBatch(records []*Record) error
+	// DeleteBatch removes the records stored under keys
+	DeleteBatch(keys [][]byte) error
+	// ForEachKey calls fn with the key of every stored record. Sinks that
+	// can't enumerate their records return without calling fn.
+	ForEachKey(fn func(key []byte) error) error
+	// GetCheckpoint returns the stored sync checkpoint or nil if there is none
+	GetCheckpoint() (*SyncCheckpoint, error)
+	// SaveCheckpoint stores checkpoint, replacing any previous checkpoint
+	SaveCheckpoint(checkpoint *SyncCheckpoint) error
+	// DeleteCheckpoint removes the stored sync checkpoint
+	DeleteCheckpoint() error
+	// Close releases the connection to the store
+	Close() error
+}
+
+// ChainTipSetter is implemented by sinks that need the height of the best chain
+// tip the records they are about to write were read at
+type ChainTipSetter interface {
+	SetChainTip(height uint64)
+}
+
+// Passes height to sink if it is a ChainTipSetter
+func setSinkChainTip(sink Sink, height uint64) {
+	if setter, ok := sink.(ChainTipSetter); ok {
+		setter.SetChainTip(height)
+	}
+}
+
+// BatchWriteError is returned by UpsertBatch and DeleteBatch when only some
+// records of a batch failed to be written
+type BatchWriteError struct {
+	// FailedKeys maps the badgerDB keys of the failed records to their error
+	FailedKeys map[string]error
+}
+
+func (batchErr *BatchWriteError) Error() string {
+	var messages []string
+	for _, err := range batchErr.FailedKeys {
+		messages = append(messages, err.Error())
+		if len(messages) == 3 {
+			break
+		}
+	}
+	return fmt.Sprintf("%d records failed to be written: %v", len(batchErr.FailedKeys),
+		strings.Join(messages, "; "))
+}
+
+// writeBatch is a batch of writes queued for the writer goroutines of a batchWriter
+type writeBatch struct {
+	// seq numbers the batches of a batchWriter in the order they were queued
+	seq     uint64
+	upserts []*Record
+	deletes [][]byte
+	failed  []*DeadLetter
+	// hashes holds the content hashes of upserts, keyed by badgerDB key
+	hashes map[string][]byte
+	// progressKey holds the key up to which every key was queued, or nil
+	progressKey []byte
+	// unsynced holds the keys that failed to be written and weren't recorded
+	// as dead letters, set once the batch was written
+	unsynced [][]byte
+}
+
+// batchWriter accumulates upserts and deletes into batches of batchSize writes
+// and hands them to concurrent writer goroutines through a bounded queue. When
+// every writer is busy and the queue is full, queuing blocks, which slows the
+// scanner down instead of buffering without limit. Records that fail to be written
+// are retried according to retry and then recorded in deadLetters unless it is
+// nil. Records whose content hash is in hashCache are skipped unless it is nil.
+// Call close to wait for the writes.
+type batchWriter struct {
+	sink        Sink
+	deadLetters DeadLetterStore
+	hashCache   *HashCache
+	batchSize   int
+	retry       RetryPolicy
+	// health is degraded while writes keep failing
+	health *healthTracker
+	// ctx cuts the backoff between retries short when it is cancelled
+	ctx context.Context
+	// current holds the batch being filled
+	current *writeBatch
+	// unchanged counts the upserts skipped since their content hash was cached
+	unchanged int
+	// queue feeds the filled batches to the writers
+	queue   chan *writeBatch
+	writers sync.WaitGroup
+	// onProgress is called with the progress key of the last batch once it and
+	// every batch queued before it were written. Calls are serialized. Progress
+	// stops at the first batch with unsynced keys.
+	onProgress func(key []byte)
+	// progressLock guards the fields tracking the written batches
+	progressLock sync.Mutex
+	nextSeq      uint64
+	writtenSeq   uint64
+	written      map[uint64]*writeBatch
+	// unsynced collects the unsynced keys of every written batch
+	unsynced [][]byte
+}
+
+// Initializes a batchWriter and starts its writers. Each of the writers
+// goroutines writes one batch at a time to sink.
+func newBatchWriter(ctx context.Context, sink Sink, deadLetters DeadLetterStore, hashCache *HashCache,
+	batchSize int, writers int, retry RetryPolicy, health *healthTracker) *batchWriter {
+	if batchSize < 1 {
+		batchSize = bulkWriteChunkSize
+	}
+	if writers < 1 {
+		writers = 1
+	}
+	bw := &batchWriter{
+		sink:        sink,
+		deadLetters: deadLetters,
+		hashCache:   hashCache,
+		batchSize:   batchSize,
+		retry:       retry,
+		health:      health,
+		ctx:         ctx,
+		queue:       make(chan *writeBatch, writers),
+		written:     make(map[uint64]*writeBatch),
+	}
+	bw.current = bw.newBatch()
+	for i := 0; i < writers; i++ {
+		bw.writers.Add(1)
+		go func() {
+			defer bw.writers.Done()
+			for batch := range bw.queue {
+				bw.write(batch)
+				bw.markWritten(batch)
+			}
+		}()
+	}
+	return bw
+}
+
+// Returns an empty batch numbered after the last queued batch
+func (bw *batchWriter) newBatch() *writeBatch {
+	bw.nextSeq++
+	return &writeBatch{seq: bw.nextSeq, hashes: make(map[string][]byte)}
+}
+
+// Queues record for upserting, unless its content didn't change since it was
+// last written
+func (bw *batchWriter) upsert(record *Record) {
+	if bw.hashCache != nil {
+		hash, err := contentHash(record.Doc)
+		if err == nil && bw.hashCache.unchanged(record.Key, hash) {
+			bw.unchanged++
+			return
+		}
+		if err == nil {
+			bw.current.hashes[string(record.Key)] = hash
+		}
+	}
+
+	bw.current.upserts = append(bw.current.upserts, record)
+	bw.flushIfFull()
+}
+
+// Queues key for deletion
+func (bw *batchWriter) delete(key []byte) {
+	bw.current.deletes = append(bw.current.deletes, key)
+	bw.flushIfFull()
+}
+
+// Queues letter for the dead-letter store
+func (bw *batchWriter) deadLetter(letter *DeadLetter) {
+	if bw.deadLetters == nil {
+		return
+	}
+	bw.current.failed = append(bw.current.failed, letter)
+	bw.flushIfFull()
+}
+
+// Records that every key up to key was queued, so that onProgress is called
+// with key once the queued writes are done
+func (bw *batchWriter) progress(key []byte) {
+	bw.current.progressKey = append([]byte{}, key...)
+}
+
+// Hands the batch being filled to the writers if it is full
+func (bw *batchWriter) flushIfFull() {
+	if len(bw.current.upserts)+len(bw.current.deletes) >= bw.batchSize ||
+		len(bw.current.failed) >= bw.batchSize {
+		bw.flush()
+	}
+}
+
+// Hands the batch being filled to the writers, blocking while the queue is full
+func (bw *batchWriter) flush() {
+	batch := bw.current
+	if len(batch.upserts) == 0 && len(batch.deletes) == 0 && len(batch.failed) == 0 &&
+		batch.progressKey == nil {
+		return
+	}
+	bw.current = bw.newBatch()
+	bw.queue <- batch
+}
+
+// Flushes the batch being filled and waits until every queued batch was written.
+// The batchWriter can't be used afterwards.
+func (bw *batchWriter) close() {
+	bw.flush()
+	close(bw.queue)
+	bw.writers.Wait()
+}
+
+// Writes batch to the sink and records its failures in the dead-letter store.
+// Failed keys that couldn't be recorded there are set as the batch's unsynced
+// keys, except for those mongoDB rejected as invalid, which fail again on every
+// attempt and are only logged.
+func (bw *batchWriter) write(batch *writeBatch) {
+	failed := batch.failed
+	// writeFailures holds the errors of the keys that failed to be written
+	writeFailures := make(map[string]error)
+	// uncached holds the keys whose cached hash no longer matches the sink
+	var uncached [][]byte
+	if len(batch.upserts) != 0 {
+		if err := bw.upsertWithRetry(batch.upserts); err != nil {
+			fmt.Printf("Failed to write batch: %v\n", err)
+			if bw.deadLetters != nil {
+				failed = append(failed, upsertDeadLetters(batch.upserts, err)...)
+			}
+			// Failed records are written again by the next pass
+			for _, record := range batch.upserts {
+				if keyErr := batchErrorForKey(err, record.Key); keyErr != nil {
+					writeFailures[string(record.Key)] = keyErr
+					delete(batch.hashes, string(record.Key))
+					uncached = append(uncached, record.Key)
+				}
+			}
+		}
+	}
+
+	if len(batch.deletes) != 0 {
+		err := bw.writeWithRetry("delete", batch.deletes, bw.sink.DeleteBatch)
+		if err != nil {
+			fmt.Printf("Failed to delete batch: %v\n", err)
+			if bw.deadLetters != nil {
+				failed = append(failed, deleteDeadLetters(batch.deletes, err)...)
+			}
+		}
+		for _, key := range batch.deletes {
+			if keyErr := batchErrorForKey(err, key); keyErr != nil {
+				writeFailures[string(key)] = keyErr
+				continue
+			}
+			uncached = append(uncached, key)
+		}
+	}
+
+	if bw.hashCache != nil && (len(batch.hashes) != 0 || len(uncached) != 0) {
+		if err := bw.hashCache.update(batch.hashes, uncached); err != nil {
+			fmt.Printf("Failed to update hash cache: %v\n", err)
+		}
+	}
+
+	// The letters of keys synced since they failed are out of date, and
+	// retrying them would overwrite the newer writes
+	if bw.deadLetters != nil {
+		var synced []string
+		for _, record := range batch.upserts {
+			if _, failed := writeFailures[string(record.Key)]; !failed {
+				synced = append(synced, hex.EncodeToString(record.Key))
+			}
+		}
+		for _, key := range batch.deletes {
+			if _, failed := writeFailures[string(key)]; !failed {
+				synced = append(synced, hex.EncodeToString(key))
+			}
+		}
+		if len(synced) != 0 {
+			if err := bw.deadLetters.RemoveDeadLetters(synced); err != nil {
+				fmt.Printf("Failed to remove the dead letters of %d synced records: %v\n", len(synced), err)
+			}
+		}
+	}
+
+	recorded := false
+	if len(failed) != 0 {
+		err := bw.deadLetters.AddDeadLetters(failed)
+		if err != nil {
+			fmt.Printf("Failed to record %d dead letters: %v\n", len(failed), err)
+		}
+		recorded = err == nil
+	}
+	if recorded {
+		return
+	}
+	for key, err := range writeFailures {
+		if writeErrorClass(err) != WriteErrorValidation {
+			batch.unsynced = append(batch.unsynced, []byte(key))
+		}
+	}
+}
+
+// Upserts records with writeWithRetry
+func (bw *batchWriter) upsertWithRetry(records []*Record) error {
+	recordsByKey := make(map[string]*Record, len(records))
+	keys := make([][]byte, len(records))
+	for i, record := range records {
+		recordsByKey[string(record.Key)] = record
+		keys[i] = record.Key
+	}
+	return bw.writeWithRetry("write", keys, func(keys [][]byte) error {
+		retried := make([]*Record, len(keys))
+		for i, key := range keys {
+			retried[i] = recordsByKey[string(key)]
+		}
+		return bw.sink.UpsertBatch(retried)
+	})
+}
+
+// Records that batch was written and reports the progress of the batches
+// written without gaps since the last report. Progress isn't reported past
+// a batch with unsynced keys, since the keys after it would be skipped when
+// resuming from the progress key.
+func (bw *batchWriter) markWritten(batch *writeBatch) {
+	bw.progressLock.Lock()
+	defer bw.progressLock.Unlock()
+
+	bw.unsynced = append(bw.unsynced, batch.unsynced...)
+	bw.written[batch.seq] = batch
+	var progressKey []byte
+	for {
+		written, ok := bw.written[bw.writtenSeq+1]
+		if !ok || len(written.unsynced) != 0 {
+			break
+		}
+		delete(bw.written, bw.writtenSeq+1)
+		bw.writtenSeq++
+		if written.progressKey != nil {
+			progressKey = written.progressKey
+		}
+	}
+	if progressKey != nil && bw.onProgress != nil {
+		bw.onProgress(progressKey)
+	}
+}
@@ -0,0 +1,178 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingSink is a Sink whose upserts of the keys in failing fail with a network error
+type failingSink struct {
+	discardSink
+	failing map[string]bool
+}
+
+func (sink failingSink) UpsertBatch(records []*Record) error {
+	failedKeys := make(map[string]error)
+	for _, record := range records {
+		if sink.failing[string(record.Key)] {
+			writeErr := newMongoWriteError("test", record.Key, -1, "connection refused")
+			writeErr.Class = WriteErrorNetwork
+			failedKeys[string(record.Key)] = writeErr
+		}
+	}
+	if len(failedKeys) == 0 {
+		return nil
+	}
+	return &BatchWriteError{FailedKeys: failedKeys}
+}
+
+// memoryDeadLetterStore is a DeadLetterStore holding its letters in memory, in
+// the order their keys were first recorded. Every AddDeadLetters call fails if
+// err is set.
+type memoryDeadLetterStore struct {
+	lock    sync.Mutex
+	letters []*DeadLetter
+	err     error
+}
+
+func (store *memoryDeadLetterStore) AddDeadLetters(letters []*DeadLetter) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	if store.err != nil {
+		return store.err
+	}
+	for _, letter := range letters {
+		replaced := false
+		for i, stored := range store.letters {
+			if stored.Key == letter.Key {
+				store.letters[i] = letter
+				replaced = true
+			}
+		}
+		if !replaced {
+			store.letters = append(store.letters, letter)
+		}
+	}
+	return nil
+}
+
+func (store *memoryDeadLetterStore) ForEachDeadLetter(fn func(letter *DeadLetter) error) error {
+	for _, letter := range store.letters {
+		if err := fn(letter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *memoryDeadLetterStore) RemoveDeadLetters(keys []string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	removed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		removed[key] = true
+	}
+	var remaining []*DeadLetter
+	for _, letter := range store.letters {
+		if !removed[letter.Key] {
+			remaining = append(remaining, letter)
+		}
+	}
+	store.letters = remaining
+	return nil
+}
+
+// Queues one upsert per key, with the key as progress key, and returns the
+// progress keys reported and the unsynced keys once they were written
+func writeProgress(sink Sink, deadLetters DeadLetterStore, writers int,
+	keys ...string) (progress []string, unsynced []string) {
+	retry := RetryPolicy{WriteAttempts: 1, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	bw := newBatchWriter(context.Background(), sink, deadLetters, nil, 1, writers, retry, &healthTracker{})
+	bw.onProgress = func(key []byte) {
+		progress = append(progress, string(key))
+	}
+	for _, key := range keys {
+		bw.upsert(&Record{Key: []byte(key), Doc: map[string]interface{}{}})
+		bw.progress([]byte(key))
+		bw.flush()
+	}
+	bw.close()
+	for _, key := range bw.unsynced {
+		unsynced = append(unsynced, string(key))
+	}
+	return progress, unsynced
+}
+
+func TestBatchWriterProgress(t *testing.T) {
+	progress, unsynced := writeProgress(discardSink{}, nil, 4, "a", "b", "c", "d")
+	if len(progress) == 0 || progress[len(progress)-1] != "d" {
+		t.Errorf("Progress %v doesn't end at the last key", progress)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] <= progress[i-1] {
+			t.Errorf("Progress %v isn't in queue order", progress)
+		}
+	}
+	if len(unsynced) != 0 {
+		t.Errorf("Got unsynced keys %v without failures", unsynced)
+	}
+}
+
+func TestBatchWriterProgressStopsAtUnsyncedKeys(t *testing.T) {
+	sink := failingSink{failing: map[string]bool{"b": true}}
+	progress, unsynced := writeProgress(sink, nil, 1, "a", "b", "c")
+	if len(progress) != 1 || progress[0] != "a" {
+		t.Errorf("Got progress %v, want [a]", progress)
+	}
+	if len(unsynced) != 1 || unsynced[0] != "b" {
+		t.Errorf("Got unsynced keys %v, want [b]", unsynced)
+	}
+}
+
+func TestBatchWriterProgressPastDeadLetters(t *testing.T) {
+	sink := failingSink{failing: map[string]bool{"b": true}}
+	deadLetters := &memoryDeadLetterStore{}
+	progress, unsynced := writeProgress(sink, deadLetters, 1, "a", "b", "c")
+	if len(progress) == 0 || progress[len(progress)-1] != "c" {
+		t.Errorf("Progress %v doesn't end at the last key", progress)
+	}
+	if len(unsynced) != 0 {
+		t.Errorf("Got unsynced keys %v for dead-lettered failures", unsynced)
+	}
+	if len(deadLetters.letters) != 1 {
+		t.Errorf("Recorded %d dead letters, want 1", len(deadLetters.letters))
+	}
+
+	// Failures that can't be recorded stop the progress
+	deadLetters = &memoryDeadLetterStore{err: errors.New("disk full")}
+	progress, unsynced = writeProgress(sink, deadLetters, 1, "a", "b", "c")
+	if len(progress) != 1 || progress[0] != "a" {
+		t.Errorf("Got progress %v, want [a]", progress)
+	}
+	if len(unsynced) != 1 || unsynced[0] != "b" {
+		t.Errorf("Got unsynced keys %v, want [b]", unsynced)
+	}
+}
+
+func TestBatchWriterRemovesDeadLettersOfSyncedKeys(t *testing.T) {
+	deadLetters := &memoryDeadLetterStore{letters: []*DeadLetter{
+		{Key: hex.EncodeToString([]byte("a"))},
+		{Key: hex.EncodeToString([]byte("b"))},
+		{Key: hex.EncodeToString([]byte("c"))},
+	}}
+	sink := failingSink{failing: map[string]bool{"b": true}}
+	writeProgress(sink, deadLetters, 1, "a", "b")
+
+	var keys []string
+	for _, letter := range deadLetters.letters {
+		keys = append(keys, letter.Key)
+	}
+	want := []string{hex.EncodeToString([]byte("b")), hex.EncodeToString([]byte("c"))}
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] {
+		t.Errorf("Got dead letters for %v, want %v", keys, want)
+	}
+}
@@ -0,0 +1,159 @@
+package mongodb
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// This file contains the per-prefix accounting of decoded badgerDB keys
+
+// PrefixCounts holds how many keys with a single prefix were handled
+type PrefixCounts struct {
+	// Seen counts the keys read from badgerDB
+	Seen int
+	// Decoded counts the keys decoded into a document
+	Decoded int
+	// Failed counts the keys with a known prefix whose value couldn't be decoded
+	Failed int
+	// Skipped counts the keys with a prefix the dumper doesn't know
+	Skipped int
+}
+
+// PrefixStats holds the PrefixCounts of every prefix seen by a pass. It is
+// safe for concurrent use.
+type PrefixStats struct {
+	counts map[byte]*PrefixCounts
+	lock   sync.Mutex
+}
+
+// Initializes and returns an empty PrefixStats
+func NewPrefixStats() *PrefixStats {
+	return &PrefixStats{
+		counts: make(map[byte]*PrefixCounts),
+	}
+}
+
+// Records that key was read and the error decoding it, if any
+func (stats *PrefixStats) record(key []byte, err error) {
+	if len(key) == 0 {
+		return
+	}
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	prefix := key[0]
+	counts, ok := stats.counts[prefix]
+	if !ok {
+		counts = &PrefixCounts{}
+		stats.counts[prefix] = counts
+	}
+
+	counts.Seen++
+	switch err {
+	case nil:
+		counts.Decoded++
+	case ErrUnknownPrefix:
+		counts.Skipped++
+	default:
+		counts.Failed++
+	}
+}
+
+// Returns the counts recorded for prefix
+func (stats *PrefixStats) Counts(prefix byte) PrefixCounts {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	if counts, ok := stats.counts[prefix]; ok {
+		return *counts
+	}
+	return PrefixCounts{}
+}
+
+// Returns the sum of the counts of all prefixes
+func (stats *PrefixStats) Total() PrefixCounts {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	var total PrefixCounts
+	for _, counts := range stats.counts {
+		total.Seen += counts.Seen
+		total.Decoded += counts.Decoded
+		total.Failed += counts.Failed
+		total.Skipped += counts.Skipped
+	}
+	return total
+}
+
+// Prints a table with the counts of every prefix seen
+func (stats *PrefixStats) Print() {
+	var prefixes []byte
+	stats.lock.Lock()
+	for prefix := range stats.counts {
+		prefixes = append(prefixes, prefix)
+	}
+	stats.lock.Unlock()
+	stats.printTable(prefixes)
+}
+
+// Prints a table with the counts of every prefix with a registered
+// decoder and of every unknown prefix seen
+func (stats *PrefixStats) PrintCoverage() {
+	prefixes := RegisteredPrefixes()
+	stats.lock.Lock()
+	for prefix := range stats.counts {
+		if GetDecoder(prefix) == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	stats.lock.Unlock()
+	stats.printTable(prefixes)
+}
+
+// Prints a table with the counts of prefixes in ascending order
+func (stats *PrefixStats) printTable(prefixes []byte) {
+	if len(prefixes) == 0 {
+		return
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return prefixes[i] < prefixes[j] })
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "Prefix\tName\tSeen\tDecoded\tFailed\tSkipped\t")
+	for _, prefix := range prefixes {
+		counts := stats.Counts(prefix)
+		fmt.Fprintf(writer, "%d\t%s\t%d\t%d\t%d\t%d\t\n", prefix, PrefixName(prefix),
+			counts.Seen, counts.Decoded, counts.Failed, counts.Skipped)
+	}
+	total := stats.Total()
+	fmt.Fprintf(writer, "\tTotal\t%d\t%d\t%d\t%d\t\n", total.Seen, total.Decoded, total.Failed, total.Skipped)
+	writer.Flush()
+}
+
+// Decodes every key in db without writing it anywhere and
+// returns how many keys of each prefix could be decoded
+func ScanPrefixCoverage(db *badger.DB) (*PrefixStats, error) {
+	stats := NewPrefixStats()
+	err := db.View(func(txn *badger.Txn) error {
+		itr := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer itr.Close()
+
+		for itr.Rewind(); itr.Valid(); itr.Next() {
+			val, err := itr.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			_, err = DecodeKeyVal(itr.Item().Key(), val)
+			stats.record(itr.Item().Key(), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -3,81 +3,128 @@ package mongodb
 import (
 	"bytes"
 	"context"
-	"encoding/binary"
-	"encoding/gob"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/deso-protocol/core/lib"
-	"log"
+	"math"
 	"math/big"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/dgraph-io/badger/v3"
+	"github.com/dgraph-io/badger/v3/pb"
 	"github.com/fatih/structs"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // This file contains all sync functions associated with badgerDB and mongoDB
 
-type SyncingService struct {
-	// DB Holds a pointer to the global badgerDB database
-	DB *badger.DB
-	// SyncDBURI holds a string URI path for connecting to the running mongoDB server
-	SyncDBURI string
-	// mongoDBName holds a string dictating what database within the mongoDB client
-	// to use for storing key/value pairs
-	mongoDBName string
-	// mongoCollectionName holds a string dictating which collection within
-	// the mongoDBName specified database to use for storing key/value pairs
-	mongoCollectionName string
-	// mongoClient is a pointer to the mongo.Client object used for interfacing
-	// with the mongo server dictated by SyncDBURI
-	mongoClient *mongo.Client
-}
-
-// Initializes and returns a new SyncingService Structure with a nil mongo client
-func NewSyncingService(db *badger.DB, syncDBURI string, mongoDBName string, mongoCollectionName string) *SyncingService {
-	return &SyncingService{
-		DB:                  db,
-		SyncDBURI:           syncDBURI,
-		mongoDBName:         mongoDBName,
-		mongoCollectionName: mongoCollectionName,
-		mongoClient:         nil,
-	}
-}
+// SyncMode dictates how the SyncingService keeps the sink up to date with badgerDB
+type SyncMode string
 
-// This file contains all sync functions associated with badgerDB and mongoDB
+const (
+	// SyncModeFull rescans and upserts every badgerDB key on every pass
+	SyncModeFull SyncMode = "full"
+	// SyncModeIncremental performs one full scan as a backfill and afterwards
+	// only upserts keys reported as changed by a badgerDB subscription
+	SyncModeIncremental SyncMode = "incremental"
+)
 
-// Establishes and returns a MongoDB client with associated URI MongoDbURI
-func (syncSrv *SyncingService) ConnectToMongo() {
-	// Establish MongoDB client options and create client
-	clientOptions := options.Client().ApplyURI(syncSrv.SyncDBURI)
-	client, err := mongo.Connect(context.Background(), clientOptions)
-	if err != nil {
-		log.Fatalf("Failed establishing a connection with MongoDB: %v", err)
-	}
+const (
+	// Default number of operations in a bulk write operation
+	bulkWriteChunkSize = 1000
+	// Time to wait between sync passes. In incremental mode a pass also
+	// runs as soon as the node connects or disconnects a block.
+	syncInterval = 60 * time.Second
+)
 
-	// Check MongoDB Connection and ensure data transmission
-	err = client.Ping(context.Background(), nil)
-	if err != nil {
-		log.Fatalf("Failed to ping MongoDB:  %v", err)
-	}
+// DocSchemaVersion is stamped on every document as SchemaVersion. Bump it
+// whenever a decoder changes the shape of the documents it produces.
+const DocSchemaVersion = 2
+
+// Sync metadata fields stamped on every document
+const (
+	badgerVersionField = "BadgerVersion"
+	tipHeightField     = "TipHeight"
+	tipBlockHashField  = "TipBlockHash"
+	firstSeenField     = "FirstSeen"
+	lastChangedField   = "LastChanged"
+	schemaVersionField = "SchemaVersion"
+)
 
-	fmt.Println("Successfully Connected to MongoDB.")
-	syncSrv.mongoClient = client
+type SyncingService struct {
+	// DB Holds a pointer to the global badgerDB database
+	DB *badger.DB
+	// Sink is the store decoded key/value pairs are written to
+	Sink Sink
+	// syncMode dictates whether every pass rescans badgerDB or only
+	// upserts the keys changed since the previous pass
+	syncMode SyncMode
+	// forceResync discards any stored checkpoint and syncs from scratch
+	forceResync bool
+	// encoding dictates how decoded values are turned into sink documents
+	encoding RecordEncoding
+	// deadLetters records the records that failed to decode or write.
+	// Failed records are only logged if it is nil.
+	deadLetters DeadLetterStore
+	// hashCache holds the content hashes of the records written to the sink,
+	// used to skip unchanged records. Every record is written if it is nil.
+	hashCache *HashCache
+	// scanWorkers is the number of goroutines decoding keys during full scans.
+	// Scans with a single worker iterate, decode and write serially.
+	scanWorkers int
+	// batchSize is the number of operations in each bulk write, and writers the
+	// number of bulk writes the sink performs concurrently
+	batchSize int
+	writers   int
+	// retry dictates how failed sink connections and bulk writes are retried
+	retry RetryPolicy
+	// health is degraded while the sink can't be connected to or written
+	health *healthTracker
+	// checkpoint holds the progress of the sync, persisted in the sink
+	checkpoint *SyncCheckpoint
+	// lastPassCheckpoint holds the read timestamp and chain tip of the
+	// previous incremental pass, which becomes the next checkpoint
+	lastPassCheckpoint *SyncCheckpoint
+	// changedKeys holds the set of badgerDB keys reported by the
+	// subscription since the last incremental pass
+	changedKeys     map[string]struct{}
+	changedKeysLock sync.Mutex
+	// mainChain holds the best DeSo chain, used to flag blocks of orphaned forks
+	mainChain *mainChain
+	// blockTipChanged is signaled by the subscription whenever the best
+	// chain tip is rewritten, i.e. a block was connected or disconnected
+	blockTipChanged chan struct{}
 }
 
-// Disconnects from MongoDB Client client
-func (syncSrv *SyncingService) DisconnectFromMongo() {
-	syncSrv.mongoClient.Disconnect(context.Background())
+// Initializes and returns a new SyncingService Structure writing to sink
+func NewSyncingService(db *badger.DB, sink Sink, syncMode SyncMode, forceResync bool,
+	encoding RecordEncoding, deadLetters DeadLetterStore, hashCache *HashCache,
+	scanWorkers int, batchSize int, writers int, retry RetryPolicy) *SyncingService {
+	return &SyncingService{
+		DB:          db,
+		Sink:        sink,
+		syncMode:    syncMode,
+		forceResync: forceResync,
+		encoding:    encoding,
+		deadLetters: deadLetters,
+		hashCache:   hashCache,
+		scanWorkers: scanWorkers,
+		batchSize:   batchSize,
+		writers:     writers,
+		retry:       retry,
+		health:      &healthTracker{health: SyncHealth{Since: time.Now()}},
+		changedKeys: make(map[string]struct{}),
+		mainChain:   &mainChain{},
+		// Buffered so that tip changes during a pass coalesce into one signal
+		blockTipChanged: make(chan struct{}, 1),
+	}
 }
 
 // Takes a map[string] interface {} and converts values into
-// easier to read formats. Additionally adds a "Time" field with
-// the current time for update purposes.
+// easier to read formats.
 func SimplifyMap(docMap *map[string]interface{}) {
 	// Go through all keys in map
 	for key, val := range *docMap {
@@ -90,6 +137,13 @@ func SimplifyMap(docMap *map[string]interface{}) {
 			subMap := (*docMap)[key].(map[string]interface{})
 			SimplifyMap(&subMap)
 			(*docMap)[key] = subMap
+		case []interface{}:
+			// structs.Map turns slices of structs into slices of maps
+			for _, elem := range (*docMap)[key].([]interface{}) {
+				if subMap, ok := elem.(map[string]interface{}); ok {
+					SimplifyMap(&subMap)
+				}
+			}
 		case lib.UtxoType:
 			(*docMap)[key] = (*docMap)[key].(lib.UtxoType).String()
 		case lib.BlockHash:
@@ -109,6 +163,12 @@ func SimplifyMap(docMap *map[string]interface{}) {
 			} else {
 				(*docMap)["ParentHash"] = nil
 			}
+		case *lib.PKID:
+			if (*docMap)[key].(*lib.PKID) == nil {
+				(*docMap)[key] = nil
+			} else {
+				(*docMap)[key] = lib.PkToStringBoth((*docMap)[key].(*lib.PKID)[:])
+			}
 		case *big.Int:
 			if (*docMap)[key].(*big.Int) == nil {
 				(*docMap)[key] = nil          
@@ -121,9 +181,14 @@ func SimplifyMap(docMap *map[string]interface{}) {
 		// e.g. uint64, []byte
 		switch key {
 		case "PKID":
-			(*docMap)[key] = lib.PkToStringBoth((*docMap)[key].([]byte))
+			if pk, ok := (*docMap)[key].([]byte); ok {
+				(*docMap)[key] = lib.PkToStringBoth(pk)
+			}
 		case "HODLerPKID":
-			pkp := val.(*lib.PKID)
+			pkp, ok := val.(*lib.PKID)
+			if !ok || pkp == nil {
+				continue
+			}
 			pkbytes := make([]byte, len(*pkp))
 			for i, v := range pkp {
 				pkbytes[i] = v
@@ -132,9 +197,14 @@ func SimplifyMap(docMap *map[string]interface{}) {
 		case "PosterPublicKey", "FollowedPublicKey", "FollowedPKID", "FollowerPKID",
 			"FollowedPublicKeys", "HoldingPublicKey", "PublicKey", "SenderPublicKey",
 			"RecipientPublicKey":
-			(*docMap)[key] = lib.PkToStringBoth((*docMap)[key].([]byte))
+			if pk, ok := (*docMap)[key].([]byte); ok {
+				(*docMap)[key] = lib.PkToStringBoth(pk)
+			}
 		case "CreatorPKID":
-			pkp := val.(*lib.PKID)
+			pkp, ok := val.(*lib.PKID)
+			if !ok || pkp == nil {
+				continue
+			}
 			pkbytes := make([]byte, len(*pkp))
 			for i, v := range pkp {
 				pkbytes[i] = v
@@ -164,708 +234,694 @@ func SimplifyMap(docMap *map[string]interface{}) {
 			(*docMap)[key] = string((*docMap)[key].([]byte))
 		case "ProfilePic":
 			(*docMap)[key] = string((*docMap)[key].([]byte))
+		case "UnlockableText":
+			(*docMap)[key] = string((*docMap)[key].([]byte))
 		}
 	}
-	(*docMap)["Time"] = time.Now().String()
 }
 
-// Takes a badgerDB iterator pointer and returns its key's
-// value formatted as a JSON
-func BadgerItrToJSON(itr *badger.Iterator) []byte {
-	key := itr.Item().Key() // Get key, val, prefix from itr
-	prefix := key[0]
-
-	// Debug setting
-	/*if prefix != 0 {
-		return nil
-	}*/
-
-	val, err := itr.Item().ValueCopy(nil)
-	if err != nil {
-		return nil
-	}
-
-	switch prefix {
-	case 0: // _PrefixBlockHashToBlock
-		blockRet := lib.NewMessage(lib.MsgTypeBlock).(*lib.MsgDeSoBlock)
-		var blockHash lib.BlockHash
-
-		err = blockRet.FromBytes(val)
-		if err != nil {
-			return nil
-		}
-		copy(blockHash[:], key[1:])
-
-		docMap := structs.Map(*blockRet)
-		docMap["BlockHash"] = blockHash
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A deso block and its corresponding blockhash."
-		docMap["BadgerKeyPrefix"] = "_PrefixBlockHashToBlock:0"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 1: // _PrefixHeightHashToNodeInfo
-		BN, err := lib.DeserializeBlockNode(val)
-		if err != nil {
-			return nil
-		}
-
-		docMap := structs.Map(BN) // Convert to map
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A block node in the deso blockchain graph."
-		docMap["BadgerKeyPrefix"] = "_PrefixHeightHashToNodeInfo:1"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 2: //_PrefixBitcoinHeightHashToNodeInfo
-		BN, err := lib.DeserializeBlockNode(val)
-		if err != nil {
-			return nil
-		}
-
-		docMap := structs.Map(BN) // Convert to map
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A block node in the bitcoin blockchain graph."
-		docMap["BadgerKeyPrefix"] = "_PrefixBitcoinHeightHashToNodeInfo:2"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-	case 3: //_KeyBestDeSoBlockHash
-		var ret lib.BlockHash
-		_, err := itr.Item().ValueCopy(ret[:])
-		if err != nil {
-			return nil
-		}
-
-		docMap := map[string]interface{}{
-			"Hash":            ret.String(),
-			"MongoMeta":       "The hash of the front of the best DeSo Chain.",
-			"BadgerKeyPrefix": "_KeyBestDeSoBlockHash:3",
-			"Time":            time.Now().String(),
-		}
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 4: //_KeyBestBitcoinHeaderHash
-		var ret lib.BlockHash
-		_, err := itr.Item().ValueCopy(ret[:])
-		if err != nil {
-			return nil
-		}
-
-		docMap := map[string]interface{}{
-			"Hash":            ret.String(),
-			"MongoMeta":       "The hash of the front of the best Bitcoin Chain.",
-			"BadgerKeyPrefix": "_KeyBestBitcoinHeaderHash:4",
-			"Time":            time.Now().String(),
-		}
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 5: //_PrefixUtxoKeyToUtxoEntry
-		var ret lib.UtxoEntry
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		err = dec.Decode(&ret)
-		if err != nil {
-			return nil
-		}
-
-		docMap := structs.Map(ret)
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A UTXO Entry."
-		docMap["BadgerKeyPrefix"] = "_PrefixUtxoKeyToUtxoEntry:5"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 6: //_PrefixPositionToUtxoKey
-		var ret lib.UtxoKey
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		err = dec.Decode(&ret)
-		if err != nil {
-			return nil
-		}
-
-		docMap := structs.Map(ret)
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A UTXO Key."
-		docMap["BadgerKeyPrefix"] = "_PrefixPositionToUtxoKey:6"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 7: //_PrefixPubKeyUtxoKey
-		var ret lib.UtxoKey
-		var newHash lib.BlockHash
-		pubKey := key[1:34]
-		copy(newHash[:], key[34:66])
-		index := binary.BigEndian.Uint32(key[66:])
-
-		ret.TxID = newHash
-		ret.Index = index
-
-		docMap := structs.Map(ret)
-		docMap["PublicKey"] = pubKey
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "Public key and UTXO key."
-		docMap["BadgerKeyPrefix"] = "_PrefixPubKeyUtxoKey:7"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 8: //_KeyUtxoNumEntries
-		numEntries := lib.DecodeUint64(val)
-
-		docMap := map[string]interface{}{
-			"UTXOs":           numEntries,
-			"MongoMeta":       "The number of utxo entries in the database.",
-			"BadgerKeyPrefix": "_KeyUtxoNumEntries:8",
-			"Time":            time.Now().String(),
+// Takes a UtxoOperation and converts it into a map holding the name of
+// its operation type and the previous entries it restores when its block
+// is disconnected. Previous entries the operation doesn't set are left out.
+func UtxoOperationToMap(utxoOp *lib.UtxoOperation) map[string]interface{} {
+	opMap := structs.Map(*utxoOp)
+	for field, val := range opMap {
+		if isNilValue(val) {
+			delete(opMap, field)
 		}
+	}
+	SimplifyMap(&opMap)
+	opMap["Type"] = utxoOp.Type.String()
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 9: //_PrefixBlockHashToUtxoOperations
-		docMap := map[string]interface{}{
-			"BadgerKeyPrefix": "_PrefixBlockHashToUtxoOperations:9",
-			"Time":            time.Now().String(),
-		}
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 10: //_KeyNanosPurchased
-		var nanosPurchased uint64
-		nanosPurchased = lib.DecodeUint64(val)
+	return opMap
+}
 
-		docMap := map[string]interface{}{
-			"Nanos":           nanosPurchased,
-			"MongoMeta":       "The number of nanos purchased thus far.",
-			"BadgerKeyPrefix": "_KeyNanosPurchased:10",
-			"Time":            time.Now().String(),
-		}
+// Takes a MessagingGroupMember and converts it into a map with its
+// public key and key name in readable form
+func messagingGroupMemberToMap(member *lib.MessagingGroupMember) map[string]interface{} {
+	return map[string]interface{}{
+		"GroupMemberPublicKey": publicKeyToString(member.GroupMemberPublicKey),
+		"GroupMemberKeyName":   groupKeyNameToString(member.GroupMemberKeyName),
+		"EncryptedKey":         member.EncryptedKey,
+	}
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Returns the Base58Check encoding of publicKey, or nil if it's not set
+func publicKeyToString(publicKey *lib.PublicKey) interface{} {
+	if publicKey == nil {
+		return nil
+	}
+	return lib.PkToStringBoth(publicKey[:])
+}
 
-	case 11: //_PrefixBitcoinBurnTxIDs
-		var newHash lib.BlockHash
-		copy(newHash[:], key[1:])
+// Returns keyName with its zero padding removed, or nil if it's not set
+func groupKeyNameToString(keyName *lib.GroupKeyName) interface{} {
+	if keyName == nil {
+		return nil
+	}
+	return string(bytes.TrimRight(keyName[:], "\x00"))
+}
 
-		docMap := map[string]interface{}{
-			"TxID":            newHash,
-			"MongoMeta":       "A processed bitcoin transaction.",
-			"BadgerKeyPrefix": "_PrefixBitcoinBurnTxIDs:11",
-			"Time":            time.Now().String(),
-		}
-		SimplifyMap(&docMap)
+// Returns true if val is nil or a nil pointer, slice or map
+func isNilValue(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(val); v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Takes a badgerDB iterator pointer and returns its key's
+// value formatted as a JSON
+func BadgerItrToJSON(itr *badger.Iterator) []byte {
+	val, err := itr.Item().ValueCopy(nil)
+	if err != nil {
+		return nil
+	}
 
-	case 12: //_PrefixPublicKeyTimestampToPrivateMessage
-		var ret lib.MessageEntry
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		err = dec.Decode(&ret)
-		if err != nil {
-			return nil
-		}
+	return BadgerKeyValToJSON(itr.Item().Key(), val)
+}
 
-		docMap := structs.Map(ret)
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "An encrypted message between two users."
-		docMap["BadgerKeyPrefix"] = "_PrefixPublicKeyTimestampToPrivateMessage:12"
+// Takes a badgerDB key and its value and returns the value
+// formatted as a JSON
+func BadgerKeyValToJSON(key []byte, val []byte) []byte {
+	docMap := BadgerKeyValToDoc(key, val)
+	if docMap == nil {
+		return nil
+	}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+	docJSON, err := json.Marshal(docMap)
+	if err != nil {
+		return nil
+	}
+	return docJSON
+}
 
-	case 13: //_KeyAccountData
-		docMap := map[string]interface{}{
-			"BadgerKeyPrefix": "_KeyAccountData:13",
-			"Time":            time.Now().String(),
-		}
+// Takes a badgerDB key and its value and returns the value as a BSON
+// document with integer and binary types preserved
+func BadgerKeyValToBSON(key []byte, val []byte) bson.M {
+	docMap := BadgerKeyValToDoc(key, val)
+	if docMap == nil {
+		return nil
+	}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+	return toBSONValue(docMap).(bson.M)
+}
 
-		// TODO: Fix with proper data in the future
-		//var accountData AccountData
-		//_ := json.Unmarshal(val, &accountData)
+// Takes a badgerDB key and its value and returns the value decoded
+// into a map. Returns nil if the key's prefix is unknown or the value
+// can't be decoded.
+func BadgerKeyValToDoc(key []byte, val []byte) map[string]interface{} {
+	docMap, err := DecodeKeyVal(key, val)
+	if err != nil {
+		return nil
+	}
+	return docMap
+}
 
-	case 14: //_KeyTransactionIndexTip
-		var ret *lib.BlockHash
-		_, err = itr.Item().ValueCopy(ret[:])
-		if err != nil {
-			return nil
-		}
+// recordStamp describes the badgerDB state a record was read at
+type recordStamp struct {
+	// BadgerVersion holds the badgerDB version of the key/value pair
+	BadgerVersion uint64
+	// TipBlockHash and TipHeight describe the best DeSo chain tip
+	// in the badgerDB transaction the record was read in
+	TipBlockHash string
+	TipHeight    uint64
+}
 
-		docMap := map[string]interface{}{
-			"Hash": ret.String(),
-			"MongoMeta": "The transaction index supports the block explorer and is only created when a node is run with --txindex." +
-				"It uses its own separate blockchain data structure to create the index, and this is the tip of that blockchain.",
-			"BadgerKeyPrefix": "_KeyTransactionIndexTip:14",
-			"Time":            time.Now().String(),
-		}
+// Adds the sync metadata fields to doc. stamp may be nil if the badgerDB
+// state the record was read at isn't known, e.g. when retrying dead letters.
+// FirstSeen and LastChanged are added by the sink, since only it knows whether
+// the document exists and whether its content changed.
+func addSyncMetadata(doc map[string]interface{}, stamp *recordStamp) {
+	if stamp != nil {
+		doc[badgerVersionField] = toBSONValue(stamp.BadgerVersion)
+		doc[tipHeightField] = toBSONValue(stamp.TipHeight)
+		doc[tipBlockHashField] = stamp.TipBlockHash
+	}
+	doc[schemaVersionField] = DocSchemaVersion
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Decodes the badgerDB key/value pair into a record using the configured
+// encoding and stamps it with the sync metadata. Returns ErrUnknownPrefix
+// if the key's prefix has no decoder.
+func (syncSrv *SyncingService) newRecord(key []byte, val []byte, stamp *recordStamp) (*Record, error) {
+	docMap, err := DecodeKeyVal(key, val)
+	if err != nil {
+		return nil, err
+	}
+	syncSrv.setChainFlags(key, docMap)
 
-	case 15: // _PrefixTransactionIDToMetadata
-		var ret lib.TransactionMetadata
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		err = dec.Decode(&ret)
+	var doc map[string]interface{}
+	if syncSrv.encoding == RecordEncodingJSON {
+		docJSON, err := json.Marshal(docMap)
 		if err != nil {
-			return nil
-		}
-
-		docMap := structs.Map(ret)
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "The transaction metadata for a particular transaction ID."
-		docMap["BadgerKeyPrefix"] = "_PrefixTransactionIDToMetadata:15"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 16: //_PrefixPublicKeyIndexToTransactionIDs
-		// TODO: Fix with proper data in the future
-		docMap := map[string]interface{}{
-			"BadgerKeyPrefix": "_KeyAccountData:13",
-			"Time":            time.Now().String(),
+			return nil, err
 		}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 17: // _PrefixPostHashToPostEntry
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		var PE lib.PostEntry
-		err = dec.Decode(&PE)
-		if err != nil {
-			return nil
+		// Unmarshal JSON into a document
+		if err := json.Unmarshal(docJSON, &doc); err != nil {
+			return nil, err
 		}
+	} else {
+		doc = toBSONValue(docMap).(bson.M)
+	}
+	addSyncMetadata(doc, stamp)
 
-		docMap := structs.Map(PE) // Convert to map
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A User's Post or Subcomment."
-		docMap["BadgerKeyPrefix"] = "_PrefixPostHashToPostEntry:17"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 18: //_PrefixPosterPublicKeyPostHash
-		var newHash lib.BlockHash
-		copy(newHash[:], key[34:])
-
-		docMap := map[string]interface{}{
-			"PublicKey":       key[1:34],
-			"PostHash":        newHash,
-			"MongoMeta":       "An association between a PostHash and its corresponding public key.",
-			"BadgerKeyPrefix": "_PrefixPosterPublicKeyPostHash:18",
-		}
-		SimplifyMap(&docMap)
+	return &Record{
+		Key:   append([]byte{}, key...),
+		Value: val,
+		Doc:   doc,
+	}, nil
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Subscribes to every change made to badgerDB and records the changed keys for
+// the next incremental pass. Closes subscribing right before subscribing. Blocks
+// until the subscription ends or ctx is cancelled.
+func (syncSrv *SyncingService) subscribeToChanges(ctx context.Context, subscribing chan<- struct{}) {
+	// Every badgerDB key begins with a single prefix byte, so matching
+	// on all possible first bytes subscribes to every key.
+	var matches []pb.Match
+	for prefix := 0; prefix <= math.MaxUint8; prefix++ {
+		matches = append(matches, pb.Match{Prefix: []byte{byte(prefix)}})
+	}
 
-	case 19: // _PrefixTstampNanosPostHash
-		var newHash lib.BlockHash
-		copy(newHash[:], key[9:])
+	close(subscribing)
+	err := syncSrv.DB.Subscribe(ctx, func(kvs *badger.KVList) error {
+		syncSrv.changedKeysLock.Lock()
+		defer syncSrv.changedKeysLock.Unlock()
 
-		docMap := map[string]interface{}{
-			"TstampNanos":     lib.DecodeUint64(key[1:9]),
-			"PostHash":        newHash,
-			"MongoMeta":       "An association between a PostHash and its corresponding time stamp (in nanos).",
-			"BadgerKeyPrefix": "_PrefixTstampNanosPostHash:19",
+		tipChanged := false
+		for _, kv := range kvs.Kv {
+			syncSrv.changedKeys[string(kv.Key)] = struct{}{}
+			if bytes.Equal(kv.Key, []byte{3}) { // _KeyBestDeSoBlockHash
+				tipChanged = true
+			}
 		}
-		SimplifyMap(&docMap)
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
 
-	case 20: // _PrefixCreatorBpsPostHash
-		var newHash lib.BlockHash
-		copy(newHash[:], key[9:])
-
-		docMap := map[string]interface{}{
-			"TstampNanos":     lib.DecodeUint64(key[1:9]),
-			"PostHash":        newHash,
-			"MongoMeta":       "An association between a PostHash and its corresponding creator basis points founder reward.",
-			"BadgerKeyPrefix": "_PrefixCreatorBpsPostHash:20",
+		// Core rewrites the tip in the same transaction that connects or
+		// disconnects a block, so the block's state changes are all queued
+		if tipChanged {
+			select {
+			case syncSrv.blockTipChanged <- struct{}{}:
+			default:
+			}
 		}
-		SimplifyMap(&docMap)
+		return nil
+	}, matches)
+	if err != nil && ctx.Err() == nil {
+		fmt.Printf("BadgerDB subscription ended: %v\n", err)
+	}
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Returns the keys changed since the last call and resets the changed key set
+func (syncSrv *SyncingService) popChangedKeys() [][]byte {
+	syncSrv.changedKeysLock.Lock()
+	defer syncSrv.changedKeysLock.Unlock()
 
-	case 21: // _PrefixMultipleBpsPostHash
-		var newHash lib.BlockHash
-		copy(newHash[:], key[9:])
+	keys := make([][]byte, 0, len(syncSrv.changedKeys))
+	for key := range syncSrv.changedKeys {
+		keys = append(keys, []byte(key))
+	}
+	syncSrv.changedKeys = make(map[string]struct{})
+	return keys
+}
 
-		docMap := map[string]interface{}{
-			"TstampNanos":     lib.DecodeUint64(key[1:9]),
-			"PostHash":        newHash,
-			"MongoMeta":       "An association between a PostHash and its multiplier basis points.",
-			"BadgerKeyPrefix": "_PrefixMultipleBpsPostHash:21",
+// Appends the keys in newKeys that aren't in keys yet
+func appendMissingKeys(keys [][]byte, newKeys [][]byte) [][]byte {
+	if len(newKeys) == 0 {
+		return keys
+	}
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		seen[string(key)] = struct{}{}
+	}
+	for _, key := range newKeys {
+		if _, ok := seen[string(key)]; !ok {
+			seen[string(key)] = struct{}{}
+			keys = append(keys, key)
 		}
-		SimplifyMap(&docMap)
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 22: // _PrefixCommentParentStakeIDToPostHash
-		var newHash lib.BlockHash
-		copy(newHash[:], key[42:])
+	}
+	return keys
+}
 
-		docMap := map[string]interface{}{
-			"ParentStakeID":   key[1:34],
-			"TstampNanos":     lib.DecodeUint64(key[34:42]),
-			"PostHash":        newHash,
-			"MongoMeta":       "An association between a comment PostHash and it's corresponding parent post stakeID.",
-			"BadgerKeyPrefix": "_PrefixCommentParentStakeIDToPostHash:22",
-		}
-		SimplifyMap(&docMap)
+// Puts the unsynced keys of a scan back into the changed key set so that the
+// next incremental pass retries them. Full syncs retry from their checkpoint instead.
+func (syncSrv *SyncingService) requeueUnsyncedKeys(summary *ScanSummary) {
+	if syncSrv.syncMode != SyncModeIncremental || len(summary.UnsyncedKeys) == 0 {
+		return
+	}
+	fmt.Printf("Retrying %d unsynced BadgerDB keys on the next pass.\n", len(summary.UnsyncedKeys))
+	syncSrv.requeueChangedKeys(summary.UnsyncedKeys)
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Puts keys back into the changed key set so they are retried on the next pass
+func (syncSrv *SyncingService) requeueChangedKeys(keys [][]byte) {
+	syncSrv.changedKeysLock.Lock()
+	defer syncSrv.changedKeysLock.Unlock()
 
-	case 23: // _PrefixPKIDToProfileEntry
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		var PE lib.ProfileEntry
-		err = dec.Decode(&PE)
-		if err != nil {
-			return nil
-		}
+	for _, key := range keys {
+		syncSrv.changedKeys[string(key)] = struct{}{}
+	}
+}
 
-		docMap := structs.Map(PE) // Convert to map
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A User's Profile."
-		docMap["BadgerKeyPrefix"] = "_PrefixProfilePubKeyToProfileEntry:23"
+// ScanSummary describes a completed scan over badgerDB
+type ScanSummary struct {
+	// ReadTs holds the badgerDB read timestamp the scan saw
+	ReadTs uint64
+	// KeysScanned counts the keys considered by the scan
+	KeysScanned int
+	// RecordsUpserted counts the keys decoded and written to the sink
+	RecordsUpserted int
+	// RecordsUnchanged counts the decoded keys whose write was skipped since
+	// their content didn't change. They are included in RecordsUpserted.
+	RecordsUnchanged int
+	// UnsyncedKeys holds the keys that failed to be written and weren't
+	// recorded as dead letters
+	UnsyncedKeys [][]byte
+	// PrefixStats holds the per-prefix counts of the keys scanned
+	PrefixStats *PrefixStats
+	// Duration holds how long the scan took
+	Duration time.Duration
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Returns how many keys the scan read per second
+func (summary *ScanSummary) Throughput() float64 {
+	if summary.Duration <= 0 {
+		return 0
+	}
+	return float64(summary.KeysScanned) / summary.Duration.Seconds()
+}
 
-	case 24: // _PrefixProfileStakeToProfilePubKey
-		docMap := map[string]interface{}{
-			"Stake":           lib.DecodeUint64(key[1:9]),
-			"PublicKey":       key[9:],
-			"MongoMeta":       "Depricated.",
-			"BadgerKeyPrefix": "_PrefixProfileStakeToProfilePubKey:24",
-		}
-		SimplifyMap(&docMap)
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 25: // _PrefixProfileUsernameToPKID
-		pubKey := val
-		docMap := map[string]interface{}{
-			"Username":        key[1:],
-			"PKID":            pubKey,
-			"MongoMeta":       "A user's username and their corresponding PKID.",
-			"BadgerKeyPrefix": "_PrefixProfileUsernameToProfilePubKey:25",
-		}
-		SimplifyMap(&docMap)
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 26: // _PrefixStakeIDTypeAmountStakeIDIndex
-		docMap := map[string]interface{}{
-			"StakeType":       "",
-			"AmountNanos":     key[2:10],
-			"StakeID":         key[10:],
-			"MongoMeta":       "A stake ID and its corresponding stakes nanos.",
-			"BadgerKeyPrefix": "_PrefixStakeIDTypeAmountStakeIDIndex:26",
-		}
-		if key[1] == 0 {
-			docMap["StakeType"] = "Post"
-		} else {
-			docMap["StakeType"] = "Profile"
-		}
-		SimplifyMap(&docMap)
+// Returns a batchWriter writing to the sink with the configured batch size,
+// writers and retries. Retries stop waiting once ctx is cancelled.
+func (syncSrv *SyncingService) newBatchWriter(ctx context.Context) *batchWriter {
+	return newBatchWriter(ctx, syncSrv.Sink, syncSrv.deadLetters, syncSrv.hashCache, syncSrv.batchSize,
+		syncSrv.writers, syncSrv.retry, syncSrv.health)
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Records the read timestamp and best chain tip a scan starts at in summary and
+// checkpoint, if it is non-nil, and moves the main chain to the tip. Returns the
+// stamp of the scan's records, whose BadgerVersion is set per record.
+func (syncSrv *SyncingService) startScan(txn *badger.Txn, summary *ScanSummary, checkpoint *SyncCheckpoint) recordStamp {
+	summary.ReadTs = txn.ReadTs()
+	tipBlockHash, tipHeight := getChainTip(txn)
+	setSinkChainTip(syncSrv.Sink, tipHeight)
+	if checkpoint != nil {
+		if checkpoint.LastSyncedKey == "" {
+			checkpoint.ScanReadTs = summary.ReadTs
+		}
+		checkpoint.TipBlockHash, checkpoint.TipHeight = tipBlockHash, tipHeight
+	}
 
-	case 27: // _KeyUSDCentsPerBitcoinExchangeRate
-		var exchange uint64
-		exchange = lib.DecodeUint64(val)
+	// Blocks whose main chain membership changed are rewritten by the next
+	// incremental pass. A full mode pass rewrites every block anyway.
+	chainKeys, err := syncSrv.followMainChain(txn)
+	if err != nil {
+		fmt.Printf("Failed to follow the main chain: %v\n", err)
+	} else if syncSrv.syncMode == SyncModeIncremental {
+		syncSrv.requeueChangedKeys(chainKeys)
+	}
 
-		docMap := map[string]interface{}{
-			"USDCentsPerBitcoin": exchange,
-			"MongoMeta":          "The exchange rate in USD Cents for a bitcoin.",
-			"BadgerKeyPrefix":    "_KeyUSDCentsPerBitcoinExchangeRate:27",
-			"Time":               time.Now().String(),
-		}
+	return recordStamp{TipBlockHash: tipBlockHash, TipHeight: tipHeight}
+}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+// Iterates over the badgerDB keys after startKey and upserts every key whose
+// version is above sinceVersion into the sink. If checkpoint is non-nil the
+// scan's progress is recorded in it after every bulk write. When ctx is
+// cancelled the scan stops, waits for the queued writes and returns ctx.Err().
+func (syncSrv *SyncingService) scan(ctx context.Context, startKey []byte, sinceVersion uint64,
+	checkpoint *SyncCheckpoint) (*ScanSummary, error) {
+	if syncSrv.scanWorkers > 1 {
+		return syncSrv.parallelScan(ctx, startKey, sinceVersion, checkpoint)
+	}
 
-	case 28: // _PrefixFollowerPKIDToFollowedPKID
-		docMap := map[string]interface{}{
-			"FollowerPKID":    key[1:34],
-			"FollowedPKID":    key[34:],
-			"MongoMeta":       "A user's PKID (follower) and the PKID of those they follow (followed).",
-			"BadgerKeyPrefix": "_PrefixFollowerPubKeyToFollowedPubKey:28",
-			"Time":            time.Now().String(),
+	summary := &ScanSummary{PrefixStats: NewPrefixStats()}
+	startTime := time.Now()
+	batch := syncSrv.newBatchWriter(ctx)
+	if checkpoint != nil {
+		batch.onProgress = func(key []byte) {
+			checkpoint.LastSyncedKey = hex.EncodeToString(key)
+			syncSrv.saveCheckpoint(checkpoint)
 		}
-		SimplifyMap(&docMap)
+	}
+	err := syncSrv.DB.View(func(txn *badger.Txn) error {
+		scanStamp := syncSrv.startScan(txn, summary, checkpoint)
+
+		itrOptions := badger.DefaultIteratorOptions
+		// Values are only read for the few keys that changed since sinceVersion
+		itrOptions.PrefetchValues = sinceVersion == 0
+		itr := txn.NewIterator(itrOptions)
+		defer itr.Close()
+
+		// Here we iterate over all keys in BadgerDB. itr.Valid() is only
+		// false if we've reached the end of BadgerDB.
+		itr.Seek(startKey)
+		if startKey != nil && itr.Valid() && bytes.Equal(itr.Item().Key(), startKey) {
+			// The start key itself was synced before the scan was interrupted
+			itr.Next()
+		}
+		for ; itr.Valid(); itr.Next() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if itr.Item().Version() <= sinceVersion {
+				continue
+			}
+			summary.KeysScanned++
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+			val, err := itr.Item().ValueCopy(nil)
+			if err != nil {
+				summary.PrefixStats.record(itr.Item().Key(), err)
+				continue
+			}
 
-	case 29: // _PrefixFollowedPubKeyToFollowerPubKey
-		docMap := map[string]interface{}{
-			"FollowedPKID":    key[1:34],
-			"FollowerPKID":    key[34:],
-			"MongoMeta":       "A user's PKID (followed) and the PKID of those who follow them (follower).",
-			"BadgerKeyPrefix": "_PrefixFollowedPubKeyToFollowerPubKey:29",
-		}
-		SimplifyMap(&docMap)
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 30: // _PrefixLikerPubKeyToLikedPostHash
-		var likedPost lib.BlockHash
-		copy(likedPost[:], key[34:])
-		docMap := map[string]interface{}{
-			"PublicKey":       key[1:34],
-			"LikedPostHash":   likedPost,
-			"MongoMeta":       "A user's public key and the post hash of one of their liked posts.",
-			"BadgerKeyPrefix": "_PrefixLikerPubKeyToLikedPostHash:30",
-		}
-		SimplifyMap(&docMap)
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 31: // _PrefixLikedPostHashToLikerPubKey
-		var likedPost lib.BlockHash
-		copy(likedPost[:], key[1:34])
-		docMap := map[string]interface{}{
-			"PublicKey":       key[1:34],
-			"LikedPostHash":   likedPost,
-			"MongoMeta":       "A PostHash and a corresponding public key of someone who liked that post.",
-			"BadgerKeyPrefix": "_PrefixLikedPostHashToLikerPubKey:31",
+			// Decode badger key/value and add record
+			stamp := scanStamp
+			stamp.BadgerVersion = itr.Item().Version()
+			record, err := syncSrv.newRecord(itr.Item().Key(), val, &stamp)
+			summary.PrefixStats.record(itr.Item().Key(), err)
+			if err != nil {
+				if err != ErrUnknownPrefix {
+					batch.deadLetter(newDeadLetter(itr.Item().Key(), val, DeadLetterStageDecode, err))
+				}
+				continue
+			}
+			summary.RecordsUpserted++
+			if checkpoint != nil {
+				batch.progress(record.Key)
+			}
+			batch.upsert(record)
 		}
-		SimplifyMap(&docMap)
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 32: // _PrefixCreatorDESOLockedNanosCreatorPKID
-		docMap := map[string]interface{}{
-			"PKID":                key[9:],
-			"DESOLockedNanos": lib.DecodeUint64(key[1:9]),
-			"MongoMeta":           "The amount of DESO locked in a particular profile's PKID.",
-			"BadgerKeyPrefix":     "_PrefixCreatorDESOLockedNanosCreatorPubKeyIIndex:32",
-		}
-		SimplifyMap(&docMap)
+		return nil
+	})
+	// Wait for the remaining bulk operations
+	batch.close()
+	summary.RecordsUnchanged = batch.unchanged
+	summary.UnsyncedKeys = batch.unsynced
+	if err != nil {
+		return nil, err
+	}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+	summary.Duration = time.Since(startTime)
+	return summary, nil
+}
 
-	case 33: // _PrefixHODLerPubKeyCreatorPubKeyToBalanceEntry
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		var BE lib.BalanceEntry
-		err = dec.Decode(&BE)
-		if err != nil {
-			return nil
-		}
+// Performs a single full scan of badgerDB into the sink, leaving the
+// stored checkpoint untouched. Stops early if ctx is cancelled.
+func (syncSrv *SyncingService) Dump(ctx context.Context) (*ScanSummary, error) {
+	return syncSrv.scan(ctx, nil, 0, nil)
+}
 
-		docMap := structs.Map(BE)
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A user's (HODLerPubKey) balance of held (CreatorPubKey)."
-		docMap["BadgerKeyPrefix"] = "_PrefixHODLerPubKeyCreatorPubKeyToBalanceEntry:33"
+// Upserts every badgerDB key into the sink, resuming after the last
+// synced key if a previous full scan was interrupted or left keys unsynced.
+// Returns whether every key was synced.
+func (syncSrv *SyncingService) fullSync(ctx context.Context) bool {
+	checkpoint := syncSrv.checkpoint
+	summary, err := syncSrv.scan(ctx, checkpoint.lastSyncedKeyBytes(), 0, checkpoint)
+	if ctx.Err() != nil {
+		fmt.Println("Full sync interrupted, it resumes from the checkpoint on restart.")
+		return false
+	}
+	if err != nil {
+		fmt.Printf("Ran into problem processing Mongo: %v\n", err)
+		return false
+	}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+	fmt.Printf("Full sync decoded %d of %d BadgerDB keys in %v (%.0f keys/s), skipping %d unchanged writes:\n",
+		summary.RecordsUpserted, summary.KeysScanned, summary.Duration, summary.Throughput(),
+		summary.RecordsUnchanged)
+	summary.PrefixStats.Print()
+	if len(summary.UnsyncedKeys) != 0 {
+		fmt.Printf("Full sync failed to write %d records, it resumes from the checkpoint.\n",
+			len(summary.UnsyncedKeys))
+		return false
+	}
 
-	case 34: // _PrefixCreatorPubKeyHODLerPubKeyToBalanceEntry
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		var BE lib.BalanceEntry
-		err = dec.Decode(&BE)
-		if err != nil {
-			return nil
-		}
+	// Keys before a resume point were synced by the original scan, so the
+	// whole database is covered up to the read timestamp of that scan.
+	checkpoint.BadgerReadTs = checkpoint.ScanReadTs
+	checkpoint.BackfillComplete = true
+	checkpoint.LastSyncedKey = ""
+	checkpoint.ScanReadTs = 0
+	syncSrv.saveCheckpoint(checkpoint)
+	return true
+}
 
-		docMap := structs.Map(BE)
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A ceator's (CreatorPubKey) hodlers (HODLerPubKey) and their associated balances."
-		docMap["BadgerKeyPrefix"] = "_PrefixCreatorPubKeyHODLerPubKeyToBalanceEntry:34"
+// Upserts every badgerDB key written since the checkpoint into the sink.
+// Covers changes the subscription could not see, e.g. while the dumper was down.
+func (syncSrv *SyncingService) catchUpSync(ctx context.Context) {
+	checkpoint := syncSrv.checkpoint
+	summary, err := syncSrv.scan(ctx, nil, checkpoint.BadgerReadTs, nil)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		fmt.Printf("Ran into problem processing Mongo: %v\n", err)
+		return
+	}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+	if summary.KeysScanned != 0 {
+		fmt.Printf("Catch-up sync decoded %d of %d changed BadgerDB keys in %v, skipping %d unchanged writes:\n",
+			summary.RecordsUpserted, summary.KeysScanned, summary.Duration, summary.RecordsUnchanged)
+		summary.PrefixStats.Print()
+	}
+	if len(summary.UnsyncedKeys) != 0 {
+		// The checkpoint stays put so that the keys are caught up again on restart
+		syncSrv.requeueUnsyncedKeys(summary)
+		return
+	}
 
-	case 35: // _PrefixPosterPublicKeyTimestampPostHash
-		var ph lib.BlockHash
-		copy(ph[:], key[42:])
+	checkpoint.BadgerReadTs = summary.ReadTs
+	syncSrv.saveCheckpoint(checkpoint)
+}
 
-		docMap := map[string]interface{}{
-			"PublicKey":       key[1:34],
-			"PostHash":        ph,
-			"TStampNanos":     lib.DecodeUint64(key[34:42]),
-			"MongoMeta":       "The PostHash of a post generated by a user's public key and the corresponding time in nanos.",
-			"BadgerKeyPrefix": "_PrefixPosterPublicKeyTimestampPostHash:35",
-		}
-		SimplifyMap(&docMap)
+// Upserts only the keys changed in badgerDB since the last pass into the sink
+func (syncSrv *SyncingService) incrementalSync(ctx context.Context) {
+	keys := syncSrv.popChangedKeys()
+	passCheckpoint := &SyncCheckpoint{}
+	stats := NewPrefixStats()
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
+	batch := syncSrv.newBatchWriter(ctx)
+	err := syncSrv.DB.View(func(txn *badger.Txn) error {
+		passCheckpoint.BadgerReadTs = txn.ReadTs()
+		passCheckpoint.TipBlockHash, passCheckpoint.TipHeight = getChainTip(txn)
+		setSinkChainTip(syncSrv.Sink, passCheckpoint.TipHeight)
 
-	case 36: // _PrefixPublicKeyToPKID
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		var PE lib.PKIDEntry
-		err = dec.Decode(&PE)
+		// Rewrite the blocks a reorg moved on or off the main chain
+		chainKeys, err := syncSrv.followMainChain(txn)
 		if err != nil {
-			return nil
-		}
-
-		docMap := structs.Map(PE)
-		pkp := docMap["PKID"].(*lib.PKID)
-		pk := make([]byte, len(*pkp))
-		for i, v := range *pkp {
-			pk[i] = v
+			fmt.Printf("Failed to follow the main chain: %v\n", err)
 		}
-		docMap["PKID"] = pk
+		keys = appendMissingKeys(keys, chainKeys)
 
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A mapping of a public key to it's corresponding PKID."
-		docMap["BadgerKeyPrefix"] = "_PrefixPublicKeyToPKID:36"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-
-	case 37: // _PrefixPKIDToPublicKey
-		docMap := map[string]interface{}{
-			"PublicKey":       val,
-			"PKID":            key[1:34],
-			"MongoMeta":       "A map of a PKID to it's corresponding public key.",
-			"BadgerKeyPrefix": "_PrefixPKIDToPublicKey:37",
-		}
-		SimplifyMap(&docMap)
+		for _, key := range keys {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			item, err := txn.Get(key)
+			if err == badger.ErrKeyNotFound {
+				// The key was deleted from badgerDB
+				batch.delete(key)
+				continue
+			}
+			if err != nil {
+				return err
+			}
 
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-	case 39: //_PrefixReposterPubKeyRepostedPostHashToRepostPostHash
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
 
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		var RE lib.RepostEntry
-		err = dec.Decode(&RE)
-		if err != nil {
-			return nil
+			// Decode badger key/value and add record
+			record, err := syncSrv.newRecord(key, val, &recordStamp{
+				BadgerVersion: item.Version(),
+				TipBlockHash:  passCheckpoint.TipBlockHash,
+				TipHeight:     passCheckpoint.TipHeight,
+			})
+			stats.record(key, err)
+			if err != nil {
+				if err != ErrUnknownPrefix {
+					batch.deadLetter(newDeadLetter(key, val, DeadLetterStageDecode, err))
+				}
+				continue
+			}
+			batch.upsert(record)
 		}
 
-		docMap := structs.Map(RE) // Convert to map
-		SimplifyMap(&docMap)
-		docMap["MongoMeta"] = "A user's public key and the post hash of one of the post they reposted"
-		docMap["BadgerKeyPrefix"] = "_PrefixReposterPubKeyRepostedPostHashToRepostPostHash:39"
-
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-	case 40: // _KeyGlobalParams
-
-		dec := gob.NewDecoder(bytes.NewReader(val))
-		var GPE lib.GlobalParamsEntry
-		err = dec.Decode(&GPE)
-		if err != nil {
-			return nil
-		}
-		docMap := structs.Map(GPE)
-		docMap["MongoMeta"] = "Global Params Entry"
-		docMap["BadgerKeyPrefix"] = "_KeyGlobalPArams"
-		docJSON, _ := json.Marshal(docMap)
-		return docJSON
-	default:
 		return nil
+	})
+	// Wait for the remaining bulk operations
+	batch.close()
+	if ctx.Err() != nil {
+		// The keys are synced by the catch-up scan on restart
+		return
 	}
-}
-
-// Starts syncing badgerDB data to mongoDB client
-func (syncSrv *SyncingService) Start() {
-	if syncSrv.mongoClient == nil {
-		fmt.Println("Failed to start mongoDB Sync. Invalid Mongo Client." +
-			"Check for proper mongoDB URI.")
+	if err != nil {
+		fmt.Printf("Ran into problem processing Mongo: %v\n", err)
+		syncSrv.requeueChangedKeys(keys)
 		return
 	}
-	mongodb := syncSrv.mongoClient.Database(syncSrv.mongoDBName)
-	MongoCollection := mongodb.Collection(syncSrv.mongoCollectionName)
 
-	for {
-		err := syncSrv.DB.View(func(txn *badger.Txn) error {
-			itr := txn.NewIterator(badger.DefaultIteratorOptions)
-			defer itr.Close()
+	if len(keys) != 0 {
+		fmt.Printf("Synced %d changed BadgerDB keys up to block %d (%s), skipping %d unchanged writes.\n",
+			len(keys), passCheckpoint.TipHeight, passCheckpoint.TipBlockHash, batch.unchanged)
+		stats.Print()
+	}
+	if len(batch.unsynced) != 0 {
+		// The checkpoint stays put until the keys are written, so that
+		// they are caught up on restart if the dumper stops before then
+		syncSrv.requeueUnsyncedKeys(&ScanSummary{UnsyncedKeys: batch.unsynced})
+		return
+	}
 
-			totalIterations := 0
-			bulkWriteChunkSize := 1000 // Number of operations in a bulk write operation
-			var ops []mongo.WriteModel
+	// Keys committed just before this pass may not have reached the
+	// subscription yet, so the checkpoint trails one pass behind.
+	if syncSrv.lastPassCheckpoint != nil {
+		syncSrv.checkpoint.BadgerReadTs = syncSrv.lastPassCheckpoint.BadgerReadTs
+		syncSrv.checkpoint.TipBlockHash = syncSrv.lastPassCheckpoint.TipBlockHash
+		syncSrv.checkpoint.TipHeight = syncSrv.lastPassCheckpoint.TipHeight
+		syncSrv.saveCheckpoint(syncSrv.checkpoint)
+	}
+	syncSrv.lastPassCheckpoint = passCheckpoint
+}
 
-			// Here we iterate over all keys in BadgerDB. itr.Valid() is only
-			// false if we've reached the end of BadgerDB.
-			for itr.Seek(nil); itr.Valid(); itr.Next() {
+// Removes the records in the sink whose badgerDB key no longer exists.
+// Covers deletions the subscription could not see, e.g. while the dumper was down.
+func (syncSrv *SyncingService) sweepDeletedKeys(ctx context.Context) {
+	totalDeleted := 0
+	batch := syncSrv.newBatchWriter(ctx)
+	err := syncSrv.DB.View(func(txn *badger.Txn) error {
+		return syncSrv.Sink.ForEachKey(func(key []byte) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			_, err := txn.Get(key)
+			if err == badger.ErrKeyNotFound {
+				batch.delete(key)
+				totalDeleted++
+				return nil
+			}
+			return err
+		})
+	})
+	// Wait for the remaining bulk operations
+	batch.close()
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		fmt.Printf("Failed to sweep deleted keys: %v\n", err)
+		return
+	}
 
-				// Execute MongoDB Bulk Write
-				if (totalIterations%bulkWriteChunkSize) == 0 && totalIterations != 0 {
-					bulkOption := options.BulkWriteOptions{}
-					bulkOption.SetOrdered(false) // Continues writes even if an error occurs
-					_, er := MongoCollection.BulkWrite(context.Background(), ops, &bulkOption)
-					ops = nil
+	fmt.Printf("Removed %d records for keys deleted from BadgerDB.\n", totalDeleted-len(batch.unsynced))
+	syncSrv.requeueUnsyncedKeys(&ScanSummary{UnsyncedKeys: batch.unsynced})
+}
 
-					if er != nil {
-						fmt.Println("Failed MongoDB bulk write")
-					} else {
-						fmt.Println("Completed MongoDB BulkWrite.")
-					}
+// Loads the checkpoint to resume from, or starts from scratch if there is
+// none or a resync was requested
+func (syncSrv *SyncingService) loadCheckpoint() {
+	if syncSrv.forceResync {
+		fmt.Println("Discarding sync checkpoint and resyncing from scratch.")
+		if err := syncSrv.Sink.DeleteCheckpoint(); err != nil {
+			fmt.Printf("Failed to delete sync checkpoint: %v\n", err)
+		}
+		if syncSrv.hashCache != nil {
+			if err := syncSrv.hashCache.Clear(); err != nil {
+				fmt.Printf("Failed to clear hash cache: %v\n", err)
+			}
+		}
+		syncSrv.checkpoint = &SyncCheckpoint{}
+		return
+	}
 
-					totalIterations = 0 // Reset total iterations to prevent overflow
-				}
+	checkpoint, err := syncSrv.Sink.GetCheckpoint()
+	if err != nil {
+		fmt.Printf("Failed to read sync checkpoint, syncing from scratch: %v\n", err)
+	}
+	if checkpoint == nil {
+		syncSrv.checkpoint = &SyncCheckpoint{}
+		return
+	}
 
-				// Convert badger iterator to JSON
-				docJSON := BadgerItrToJSON(itr)
-				if docJSON == nil {
-					continue
-				}
+	fmt.Printf("Resuming from sync checkpoint at BadgerDB read timestamp %d and block height %d.\n",
+		checkpoint.BadgerReadTs, checkpoint.TipHeight)
+	syncSrv.checkpoint = checkpoint
+}
 
-				// Unmarshal JSON into BSON
-				var docBSON map[string]interface{}
-				err := json.Unmarshal(docJSON, &docBSON)
-				if err != nil {
-					continue
+// Syncs badgerDB data to the sink until ctx is cancelled. The sink must be
+// connected. Returns once the writes of the interrupted pass are done and the
+// checkpoint is saved.
+func (syncSrv *SyncingService) Start(ctx context.Context) {
+	syncSrv.loadCheckpoint()
+	syncSrv.loadMainChain()
+	defer func() {
+		syncSrv.saveCheckpoint(syncSrv.checkpoint)
+		fmt.Println("Sync stopped.")
+	}()
+
+	if syncSrv.syncMode == SyncModeIncremental {
+		// Subscribe before the backfill so that changes made while the full
+		// scan is running are picked up by the next pass. Badger registers the
+		// subscriber inside DB.Subscribe without signalling it, so the keys
+		// written after subscribedTs may be missed by the subscription. The
+		// catch-up scan below rescans them once the subscription is running.
+		var subscribedTs uint64
+		syncSrv.DB.View(func(txn *badger.Txn) error {
+			subscribedTs = txn.ReadTs()
+			return nil
+		})
+		subscribing := make(chan struct{})
+		go syncSrv.subscribeToChanges(ctx, subscribing)
+		<-subscribing
+
+		if !syncSrv.checkpoint.BackfillComplete || syncSrv.checkpoint.LastSyncedKey != "" {
+			// Changed keys can only be synced incrementally once the backfill is complete
+			for retry := 1; !syncSrv.fullSync(ctx); retry++ {
+				wait := syncSrv.retry.backoff(retry)
+				fmt.Printf("Retrying full sync in %v.\n", wait)
+				if !sleepContext(ctx, wait) {
+					return
 				}
-
-				// Create and add operation
-				op := mongo.NewUpdateOneModel()
-				op.SetFilter(bson.M{"_id": string(itr.Item().Key())})
-				op.SetUpdate(bson.M{"$set": docBSON})
-				op.SetUpsert(true)
-				ops = append(ops, op)
-				totalIterations++
 			}
+		}
 
-			// Push remaining bulk operations
-			if totalIterations != 0 {
-				bulkOption := options.BulkWriteOptions{}
-				bulkOption.SetOrdered(false)
-				_, err := MongoCollection.BulkWrite(context.Background(), ops[0:totalIterations], &bulkOption)
+		// Catches up the keys written while the dumper was down, including those
+		// before the resume point of a backfill, and since subscribedTs
+		if syncSrv.checkpoint.BadgerReadTs > subscribedTs {
+			syncSrv.checkpoint.BadgerReadTs = subscribedTs
+		}
+		syncSrv.catchUpSync(ctx)
+		syncSrv.sweepDeletedKeys(ctx)
 
-				if err != nil {
-					fmt.Println("Failed bulk write")
-				}
+		for ctx.Err() == nil {
+			// Sync the state changes of each block as it arrives, and at least
+			// every syncInterval to pick up changes made outside of blocks
+			select {
+			case <-ctx.Done():
+				return
+			case <-syncSrv.blockTipChanged:
+			case <-time.After(syncInterval):
 			}
+			syncSrv.incrementalSync(ctx)
+		}
+		return
+	}
 
-			// Wait a minute before conintuing to limit CPU utilization
-			time.Sleep(60 * time.Second)
+	for ctx.Err() == nil {
+		syncSrv.fullSync(ctx)
+		syncSrv.sweepDeletedKeys(ctx)
 
-			return nil
-		})
-		if err != nil {
-			fmt.Println("Ran into problem processing Mongo...")
+		// Wait a minute before conintuing to limit CPU utilization
+		select {
+		case <-ctx.Done():
+		case <-time.After(syncInterval):
 		}
 	}
 }
@@ -0,0 +1,229 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// This file contains the classification and reporting of failed mongoDB writes
+
+// WriteErrorClass groups the causes of failed writes by how they can be handled
+type WriteErrorClass string
+
+const (
+	// WriteErrorDuplicateKey is a write that violated a unique index, e.g. when
+	// two upserts create the same document at once. Retrying usually succeeds.
+	WriteErrorDuplicateKey WriteErrorClass = "duplicate-key"
+	// WriteErrorValidation is a document mongoDB rejected, e.g. for being too
+	// large or failing a validator. Retrying fails again.
+	WriteErrorValidation WriteErrorClass = "validation"
+	// WriteErrorNetwork is a write that didn't reach mongoDB or whose answer was lost
+	WriteErrorNetwork WriteErrorClass = "network"
+	// WriteErrorWriteConcern is a write mongoDB couldn't acknowledge with the
+	// requested write concern, e.g. during a replica set election
+	WriteErrorWriteConcern WriteErrorClass = "write-concern"
+	// WriteErrorOther is any other error mongoDB answered with
+	WriteErrorOther WriteErrorClass = "other"
+)
+
+// Maximum number of write errors logged for each bulk write
+const maxLoggedWriteErrors = 10
+
+// Error codes mongoDB answers with when a write violates a unique index
+var duplicateKeyErrorCodes = map[int]bool{
+	duplicateKeyErrorCode: true,
+	11001:                 true, // DuplicateKey on update
+	12582:                 true, // DuplicateKey on insert into a legacy index
+}
+
+// Error codes mongoDB answers with when it rejects a document
+var validationErrorCodes = map[int]bool{
+	2:     true, // BadValue
+	14:    true, // TypeMismatch
+	52:    true, // DollarPrefixedFieldName
+	55:    true, // InvalidDBRef
+	56:    true, // EmptyFieldName
+	57:    true, // DottedFieldName
+	66:    true, // ImmutableField
+	121:   true, // DocumentValidationFailure
+	10334: true, // BSONObjectTooLarge
+	17419: true, // Updated document too large
+	17420: true, // Upserted document too large
+}
+
+// Returns the class of a write error mongoDB answered with code
+func classifyErrorCode(code int) WriteErrorClass {
+	if duplicateKeyErrorCodes[code] {
+		return WriteErrorDuplicateKey
+	}
+	if validationErrorCodes[code] {
+		return WriteErrorValidation
+	}
+	return WriteErrorOther
+}
+
+// MongoWriteError describes why the document of a badgerDB key failed to be written
+type MongoWriteError struct {
+	Class WriteErrorClass
+	// Code holds the error code mongoDB answered with, or 0 if it didn't answer
+	Code int
+	// Message holds the error message
+	Message string
+	// Collection holds the collection the document was written to
+	Collection string
+	// Index holds the position of the write in its bulk write, or -1 if the
+	// whole operation failed
+	Index int
+	// DocumentID holds the _id of the document
+	DocumentID string
+	// Prefix holds the badgerDB key prefix of the document
+	Prefix byte
+}
+
+func (writeErr *MongoWriteError) Error() string {
+	return fmt.Sprintf("MongoDB %v error %d writing %v (prefix %d) to %v: %v", writeErr.Class,
+		writeErr.Code, writeErr.DocumentID, writeErr.Prefix, writeErr.Collection, writeErr.Message)
+}
+
+// Returns a MongoWriteError for key without its class and code
+func newMongoWriteError(collection string, key []byte, index int, message string) *MongoWriteError {
+	writeErr := &MongoWriteError{
+		Message:    message,
+		Collection: collection,
+		Index:      index,
+		DocumentID: documentID(key),
+	}
+	if len(key) != 0 {
+		writeErr.Prefix = key[0]
+	}
+	return writeErr
+}
+
+// Returns the MongoWriteError of key when err failed the whole operation on collection
+func newOperationWriteError(collection string, key []byte, err error) *MongoWriteError {
+	writeErr := newMongoWriteError(collection, key, -1, err.Error())
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && !cmdErr.HasErrorLabel("NetworkError") {
+		writeErr.Code = int(cmdErr.Code)
+		writeErr.Class = classifyErrorCode(writeErr.Code)
+		return writeErr
+	}
+	// Errors other than the server's answers come from server selection
+	// timeouts, dropped connections and the like
+	writeErr.Class = WriteErrorNetwork
+	return writeErr
+}
+
+// Returns the class of a key's error in a BatchWriteError. Errors of sinks
+// other than mongoDB are WriteErrorOther.
+func writeErrorClass(err error) WriteErrorClass {
+	var writeErr *MongoWriteError
+	if errors.As(err, &writeErr) {
+		return writeErr.Class
+	}
+	return WriteErrorOther
+}
+
+// Returns the errors of a failed bulk write to collection, keyed by the badgerDB
+// keys of the writes in keys
+func bulkWriteFailures(collection string, keys [][]byte, err error) map[string]error {
+	failedKeys := make(map[string]error)
+
+	bulkErr, ok := err.(mongo.BulkWriteException)
+	if !ok {
+		// The whole bulk write failed
+		for _, key := range keys {
+			failedKeys[string(key)] = newOperationWriteError(collection, key, err)
+		}
+		return failedKeys
+	}
+
+	if bulkErr.WriteConcernError != nil {
+		// The writes without a write error may not have been applied either
+		for _, key := range keys {
+			writeErr := newMongoWriteError(collection, key, -1, bulkErr.WriteConcernError.Message)
+			writeErr.Class = WriteErrorWriteConcern
+			writeErr.Code = bulkErr.WriteConcernError.Code
+			failedKeys[string(key)] = writeErr
+		}
+	}
+	for _, bulkWriteErr := range bulkErr.WriteErrors {
+		if bulkWriteErr.Index < 0 || bulkWriteErr.Index >= len(keys) {
+			continue
+		}
+		key := keys[bulkWriteErr.Index]
+		writeErr := newMongoWriteError(collection, key, bulkWriteErr.Index, bulkWriteErr.Message)
+		writeErr.Code = bulkWriteErr.Code
+		writeErr.Class = classifyErrorCode(bulkWriteErr.Code)
+		failedKeys[string(key)] = writeErr
+	}
+	return failedKeys
+}
+
+// Returns the number of errors of each class in failedKeys, e.g. "3 duplicate-key, 1 validation"
+func countWriteErrorClasses(failedKeys map[string]error) string {
+	counts := make(map[WriteErrorClass]int)
+	for _, err := range failedKeys {
+		counts[writeErrorClass(err)]++
+	}
+
+	var parts []string
+	for class, count := range counts {
+		parts = append(parts, fmt.Sprintf("%d %v", count, class))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// Logs the outcome of a bulk write of total ops to collection. result may be nil.
+func logBulkWrite(collection string, total int, result *mongo.BulkWriteResult, failedKeys map[string]error) {
+	counts := ""
+	if result != nil {
+		counts = fmt.Sprintf(" (%d matched, %d modified, %d upserted, %d inserted, %d deleted)",
+			result.MatchedCount, result.ModifiedCount, result.UpsertedCount, result.InsertedCount,
+			result.DeletedCount)
+	}
+	if len(failedKeys) == 0 {
+		fmt.Printf("Completed MongoDB bulk write of %d ops to %v%v.\n", total, collection, counts)
+		return
+	}
+
+	fmt.Printf("Failed MongoDB bulk write to %v for %d of %d ops%v: %v\n", collection,
+		len(failedKeys), total, counts, countWriteErrorClasses(failedKeys))
+
+	// Log the write errors in the order of the bulk write
+	var writeErrs []*MongoWriteError
+	for _, err := range failedKeys {
+		if writeErr, ok := err.(*MongoWriteError); ok {
+			writeErrs = append(writeErrs, writeErr)
+		}
+	}
+	sort.Slice(writeErrs, func(i, j int) bool {
+		if writeErrs[i].Index != writeErrs[j].Index {
+			return writeErrs[i].Index < writeErrs[j].Index
+		}
+		return writeErrs[i].DocumentID < writeErrs[j].DocumentID
+	})
+	if len(writeErrs) != 0 && writeErrs[0].Index < 0 {
+		// Errors of the whole operation are shared by its writes
+		fmt.Printf("  %v error %d: %v\n", writeErrs[0].Class, writeErrs[0].Code, writeErrs[0].Message)
+	}
+	logged := 0
+	for i, writeErr := range writeErrs {
+		if writeErr.Index < 0 {
+			continue
+		}
+		if logged == maxLoggedWriteErrors {
+			fmt.Printf("  ... and %d more write errors\n", len(writeErrs)-i)
+			break
+		}
+		logged++
+		fmt.Printf("  index %d: %v error %d for _id %v (prefix %d): %v\n", writeErr.Index,
+			writeErr.Class, writeErr.Code, writeErr.DocumentID, writeErr.Prefix, writeErr.Message)
+	}
+}
@@ -0,0 +1,67 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestBulkWriteFailures(t *testing.T) {
+	keys := [][]byte{{5, 1}, {5, 2}, {5, 3}}
+
+	// Write errors fail only their own writes
+	bulkErr := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 0, Code: duplicateKeyErrorCode, Message: "duplicate"}},
+		{WriteError: mongo.WriteError{Index: 2, Code: 10334, Message: "too large"}},
+		{WriteError: mongo.WriteError{Index: 7, Code: 1, Message: "out of range"}},
+	}}
+	failedKeys := bulkWriteFailures("posts", keys, bulkErr)
+	if len(failedKeys) != 2 {
+		t.Fatalf("Got %d failed keys, want 2", len(failedKeys))
+	}
+	if class := writeErrorClass(failedKeys[string(keys[0])]); class != WriteErrorDuplicateKey {
+		t.Errorf("Got class %v for a duplicate key, want %v", class, WriteErrorDuplicateKey)
+	}
+	if class := writeErrorClass(failedKeys[string(keys[2])]); class != WriteErrorValidation {
+		t.Errorf("Got class %v for a too large document, want %v", class, WriteErrorValidation)
+	}
+	writeErr := failedKeys[string(keys[2])].(*MongoWriteError)
+	if writeErr.Index != 2 || writeErr.Collection != "posts" || writeErr.Prefix != 5 {
+		t.Errorf("Got %+v, want index 2 in posts with prefix 5", writeErr)
+	}
+
+	// Write concern errors fail every write
+	bulkErr = mongo.BulkWriteException{WriteConcernError: &mongo.WriteConcernError{Code: 64, Message: "timeout"}}
+	failedKeys = bulkWriteFailures("posts", keys, bulkErr)
+	if len(failedKeys) != len(keys) {
+		t.Fatalf("Got %d failed keys, want %d", len(failedKeys), len(keys))
+	}
+	for _, err := range failedKeys {
+		if class := writeErrorClass(err); class != WriteErrorWriteConcern {
+			t.Errorf("Got class %v, want %v", class, WriteErrorWriteConcern)
+		}
+	}
+
+	// Errors of the whole operation fail every write
+	tests := []struct {
+		err   error
+		class WriteErrorClass
+	}{
+		{errors.New("server selection timeout"), WriteErrorNetwork},
+		{mongo.CommandError{Code: 121, Message: "validation failed"}, WriteErrorValidation},
+		{mongo.CommandError{Code: 8000, Message: "quota exceeded"}, WriteErrorOther},
+		{mongo.CommandError{Code: 6, Labels: []string{"NetworkError"}}, WriteErrorNetwork},
+	}
+	for _, test := range tests {
+		failedKeys = bulkWriteFailures("posts", keys, test.err)
+		if len(failedKeys) != len(keys) {
+			t.Fatalf("Got %d failed keys for %v, want %d", len(failedKeys), test.err, len(keys))
+		}
+		for _, err := range failedKeys {
+			if class := writeErrorClass(err); class != test.class {
+				t.Errorf("Got class %v for %v, want %v", class, test.err, test.class)
+			}
+		}
+	}
+}